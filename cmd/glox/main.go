@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"log"
+	"os"
+	"strconv"
+	"strings"
+
+	"github.com/alexmarchant/glox"
+)
+
+func main() {
+	lox := glox.NewLox()
+
+	// Fixed seed for reproducible random() in test runs
+	if seed, err := strconv.ParseInt(os.Getenv("GLOX_SEED"), 10, 64); err == nil {
+		lox.Interpreter.Random.Seed(seed)
+	}
+
+	// Anything after the script path is passed to the script's args()
+	args := os.Args[1:]
+	if len(args) > 0 && args[0] == "-h" {
+		fmt.Println("Usage: golox [script [args...]]")
+		os.Exit(64)
+	} else if len(args) >= 1 {
+		lox.Interpreter.Args = args[1:]
+		runFile(lox, args[0])
+	} else {
+		runPrompt(lox)
+	}
+}
+
+func runFile(lox *glox.Lox, path string) {
+	bytes, err := ioutil.ReadFile(path)
+	if err != nil {
+		log.Fatal(err)
+	}
+	source := string(bytes)
+	err = lox.Run(context.Background(), source)
+	exitIfExited(err)
+
+	if lox.HadError {
+		os.Exit(65)
+	}
+
+	if lox.HadRuntimeError {
+		os.Exit(70)
+	}
+}
+
+func runPrompt(lox *glox.Lox) {
+	// Shared with readLine(), so neither reads ahead of the other
+	reader := lox.Interpreter.Stdin
+
+	for {
+		fmt.Print("-> ")
+		text, err := reader.ReadString('\n')
+		if err != nil && text == "" {
+			// Stdin is closed
+			fmt.Println()
+			return
+		}
+		// convert CRLF to LF
+		text = strings.Replace(text, "\n", "", -1)
+		err = lox.Run(context.Background(), text)
+		exitIfExited(err)
+		lox.ResetErrorState()
+	}
+}
+
+// exitIfExited exits with the script's status if it called exit().
+func exitIfExited(err error) {
+	if runtimeErr, ok := err.(*glox.RuntimeError); ok && runtimeErr.IsExit {
+		os.Exit(runtimeErr.ExitCode)
+	}
+}
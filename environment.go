@@ -1,8 +1,16 @@
-package main
+package glox
 
+import "sync"
+
+// Environment is safe to share between goroutines, since tasks run
+// closures over the same variables.
 type Environment struct {
 	Enclosing *Environment
 	Values    map[string]interface{}
+	// Names declared with const. The resolver stops scripts assigning
+	// to local ones, but globals can be assigned to from anywhere.
+	constants map[string]bool
+	mu        sync.RWMutex
 }
 
 func NewEnvironment(enclosing *Environment) *Environment {
@@ -13,14 +21,36 @@ func NewEnvironment(enclosing *Environment) *Environment {
 }
 
 func (e *Environment) define(name string, value interface{}) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
 	e.Values[name] = value
+	delete(e.constants, name)
+}
+
+func (e *Environment) defineConstant(name string, value interface{}) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.Values[name] = value
+	if e.constants == nil {
+		e.constants = map[string]bool{}
+	}
+	e.constants[name] = true
 }
 
 func (e *Environment) assign(name *Token, value interface{}) *RuntimeError {
+	e.mu.Lock()
 	if _, ok := e.Values[name.Lexeme]; ok {
+		defer e.mu.Unlock()
+		if e.constants[name.Lexeme] {
+			return &RuntimeError{
+				Token:   name,
+				Message: "Cannot assign to constant '" + name.Lexeme + "'.",
+			}
+		}
 		e.Values[name.Lexeme] = value
 		return nil
 	}
+	e.mu.Unlock()
 
 	if e.Enclosing != nil {
 		return e.Enclosing.assign(name, value)
@@ -33,12 +63,15 @@ func (e *Environment) assign(name *Token, value interface{}) *RuntimeError {
 }
 
 func (e *Environment) assignAt(distance int, name *Token, value interface{}) *RuntimeError {
-	e.ancestor(distance).Values[name.Lexeme] = value
+	ancestor := e.ancestor(distance)
+	ancestor.mu.Lock()
+	defer ancestor.mu.Unlock()
+	ancestor.Values[name.Lexeme] = value
 	return nil
 }
 
 func (e *Environment) get(name *Token) (interface{}, *RuntimeError) {
-	if val, ok := e.Values[name.Lexeme]; ok {
+	if val, ok := e.lookup(name.Lexeme); ok {
 		return val, nil
 	}
 
@@ -53,7 +86,22 @@ func (e *Environment) get(name *Token) (interface{}, *RuntimeError) {
 }
 
 func (e *Environment) getAt(distance int, name string) (interface{}, *RuntimeError) {
-	return e.ancestor(distance).Values[name], nil
+	val, _ := e.ancestor(distance).lookup(name)
+	return val, nil
+}
+
+// lookup finds a variable in this environment only.
+func (e *Environment) lookup(name string) (interface{}, bool) {
+	e.mu.RLock()
+	defer e.mu.RUnlock()
+	val, ok := e.Values[name]
+	return val, ok
+}
+
+func (e *Environment) remove(name string) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	delete(e.Values, name)
 }
 
 func (e *Environment) ancestor(distance int) *Environment {
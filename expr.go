@@ -1,4 +1,4 @@
-package main
+package glox
 
 type Expr interface {
 	Accept(ExprVisitor) (interface{}, *RuntimeError)
@@ -17,6 +17,15 @@ type ExprVisitor interface {
 	VisitAssignExpr(*AssignExpr) (interface{}, *RuntimeError)
 	VisitLogicalExpr(*LogicalExpr) (interface{}, *RuntimeError)
 	VisitSuperExpr(*SuperExpr) (interface{}, *RuntimeError)
+	VisitListExpr(*ListExpr) (interface{}, *RuntimeError)
+	VisitMapExpr(*MapExpr) (interface{}, *RuntimeError)
+	VisitIndexExpr(*IndexExpr) (interface{}, *RuntimeError)
+	VisitIndexSetExpr(*IndexSetExpr) (interface{}, *RuntimeError)
+	VisitSpawnExpr(*SpawnExpr) (interface{}, *RuntimeError)
+	VisitAwaitExpr(*AwaitExpr) (interface{}, *RuntimeError)
+	VisitMatchExpr(*MatchExpr) (interface{}, *RuntimeError)
+	VisitDestructureExpr(*DestructureExpr) (interface{}, *RuntimeError)
+	VisitConditionalExpr(*ConditionalExpr) (interface{}, *RuntimeError)
 }
 
 type LiteralExpr struct {
@@ -38,7 +47,11 @@ func (t *UnaryExpr) Accept(visitor ExprVisitor) (interface{}, *RuntimeError) {
 
 type AssignExpr struct {
 	Name *Token
+	// The binary operator of compound assignments like +=, nil for =
+	Operator *Token
 	Value Expr
+	// Set for x++ and x--, which evaluate to the value before
+	Postfix bool
 }
 
 func (t *AssignExpr) Accept(visitor ExprVisitor) (interface{}, *RuntimeError) {
@@ -94,6 +107,7 @@ type CallExpr struct {
 	Callee Expr
 	Paren *Token
 	Arguments []Expr
+	Names []*Token
 }
 
 func (t *CallExpr) Accept(visitor ExprVisitor) (interface{}, *RuntimeError) {
@@ -103,6 +117,8 @@ func (t *CallExpr) Accept(visitor ExprVisitor) (interface{}, *RuntimeError) {
 type GetExpr struct {
 	Object Expr
 	Name *Token
+	// Accessed with ?., so a nil object skips the rest of the chain
+	Optional bool
 }
 
 func (t *GetExpr) Accept(visitor ExprVisitor) (interface{}, *RuntimeError) {
@@ -112,7 +128,9 @@ func (t *GetExpr) Accept(visitor ExprVisitor) (interface{}, *RuntimeError) {
 type SetExpr struct {
 	Object Expr
 	Name *Token
+	Operator *Token
 	Value Expr
+	Postfix bool
 }
 
 func (t *SetExpr) Accept(visitor ExprVisitor) (interface{}, *RuntimeError) {
@@ -127,3 +145,103 @@ func (t *ThisExpr) Accept(visitor ExprVisitor) (interface{}, *RuntimeError) {
 	return visitor.VisitThisExpr(t)
 }
 
+type ListExpr struct {
+	Bracket *Token
+	Elements []Expr
+}
+
+func (t *ListExpr) Accept(visitor ExprVisitor) (interface{}, *RuntimeError) {
+	return visitor.VisitListExpr(t)
+}
+
+type MapExpr struct {
+	Brace *Token
+	Keys []Expr
+	Values []Expr
+}
+
+func (t *MapExpr) Accept(visitor ExprVisitor) (interface{}, *RuntimeError) {
+	return visitor.VisitMapExpr(t)
+}
+
+type IndexExpr struct {
+	Object Expr
+	Bracket *Token
+	Index Expr
+}
+
+func (t *IndexExpr) Accept(visitor ExprVisitor) (interface{}, *RuntimeError) {
+	return visitor.VisitIndexExpr(t)
+}
+
+type IndexSetExpr struct {
+	Object Expr
+	Bracket *Token
+	Index Expr
+	Operator *Token
+	Value Expr
+	Postfix bool
+}
+
+func (t *IndexSetExpr) Accept(visitor ExprVisitor) (interface{}, *RuntimeError) {
+	return visitor.VisitIndexSetExpr(t)
+}
+
+type SpawnExpr struct {
+	Keyword *Token
+	Call *CallExpr
+}
+
+func (t *SpawnExpr) Accept(visitor ExprVisitor) (interface{}, *RuntimeError) {
+	return visitor.VisitSpawnExpr(t)
+}
+
+type AwaitExpr struct {
+	Keyword *Token
+	Value Expr
+}
+
+func (t *AwaitExpr) Accept(visitor ExprVisitor) (interface{}, *RuntimeError) {
+	return visitor.VisitAwaitExpr(t)
+}
+
+type MatchExpr struct {
+	Keyword *Token
+	Value Expr
+	Arms []*MatchArm
+}
+
+func (t *MatchExpr) Accept(visitor ExprVisitor) (interface{}, *RuntimeError) {
+	return visitor.VisitMatchExpr(t)
+}
+
+type MatchArm struct {
+	Pattern Pattern
+	// Checked after the pattern matches, nil if the arm has no if
+	Guard Expr
+	Body Expr
+}
+
+// DestructureExpr assigns the elements of a list to Targets, as in
+// `[a, b] = [b, a]`. Targets are variables, properties, indexes or
+// nested lists of them.
+type DestructureExpr struct {
+	Bracket *Token
+	Targets []Expr
+	Value Expr
+}
+
+func (t *DestructureExpr) Accept(visitor ExprVisitor) (interface{}, *RuntimeError) {
+	return visitor.VisitDestructureExpr(t)
+}
+
+type ConditionalExpr struct {
+	Condition Expr
+	Question *Token
+	Then Expr
+	Else Expr
+}
+
+func (t *ConditionalExpr) Accept(visitor ExprVisitor) (interface{}, *RuntimeError) {
+	return visitor.VisitConditionalExpr(t)
+}
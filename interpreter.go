@@ -1,7 +1,16 @@
-package main
+package glox
 
 import (
+	"bufio"
+	"context"
 	"fmt"
+	"math"
+	"math/rand"
+	"os"
+	"reflect"
+	"strings"
+	"sync"
+	"time"
 )
 
 type Interpreter struct {
@@ -9,12 +18,60 @@ type Interpreter struct {
 	Globals     *Environment
 	Environment *Environment
 	Locals      map[Expr]int
+	Frames      []*CallFrame
+	// Source for math.random() and math.randomInt(). Seed it, or call
+	// math.seed() from a script, for reproducible runs.
+	Random *rand.Rand
+	// Script arguments for args(), and the reader for readLine()
+	Args  []string
+	Stdin *bufio.Reader
+	// Guards Stdin, which tasks share
+	stdinMu *sync.Mutex
+	// Checked while a script runs, see Interpret
+	Limits Limits
+
+	budget *budget
+	// Runs async bodies and timers, see runLoop
+	loop *eventLoop
+	// Set on the interpreters running generator bodies
+	coroutine *coroutine
 }
 
 type RuntimeError struct {
 	Token   *Token
 	Message string
 	Return  interface{}
+	// Set for return statements, since Return alone can't tell
+	// `return;` apart from a real error
+	IsReturn bool
+	// Set along with IsReturn for tail calls, which the function
+	// returning makes in its caller's place
+	TailCall *TailCall
+	// Set for break statements, for the enclosing loop or switch to stop
+	IsBreak bool
+	// Set when the script ran out of one of its Limits, or its context
+	// was done. Scripts can't recover from these.
+	IsLimit bool
+	// Set by exit(), which leaves it to whoever ran the script to exit
+	// with ExitCode
+	IsExit   bool
+	ExitCode int
+	Trace    []*CallFrame
+}
+
+// Error formats the error for embedders, who get it back from Call.
+func (e *RuntimeError) Error() string {
+	if e.Token == nil {
+		return e.Message
+	}
+	return fmt.Sprintf("[line %d] %s", e.Token.Line, e.Message)
+}
+
+// CallFrame records a call in progress: what was called and the line
+// it was called from.
+type CallFrame struct {
+	Name string
+	Line int
 }
 
 func NewInterpreter() *Interpreter {
@@ -23,24 +80,64 @@ func NewInterpreter() *Interpreter {
 	// Native functions
 	env.define("clock", &ClockNativeFunc{})
 	env.define("print", &PrintNativeFunc{})
+	env.define("type", &TypeNativeFunc{})
+	env.define("fields", &FieldsNativeFunc{})
+	env.define("methods", &MethodsNativeFunc{})
+	env.define("superclass", &SuperclassNativeFunc{})
+	env.define("hasField", &HasFieldNativeFunc{})
+	env.define("getField", &GetFieldNativeFunc{})
+	env.define("range", &NativeFunction{Name: "range", Signature: Arity{Min: 1, Max: 3}, Fn: rangeNative})
+	env.define("Channel", &NativeFunction{Name: "Channel", Signature: Arity{Min: 0, Max: 1}, Fn: channelNative})
+	env.define("WaitGroup", &NativeFunction{Name: "WaitGroup", Signature: Arity{Min: 0, Max: 0}, Fn: waitGroupNative})
+	env.define("setTimeout", &NativeFunction{Name: "setTimeout", Signature: Arity{Min: 2, Max: 2}, Fn: setTimeoutNative})
+	for _, native := range conversionNatives {
+		env.define(native.Name, native)
+	}
+	for _, native := range ioNatives {
+		env.define(native.Name, native)
+	}
+	env.define("math", mathModule)
+	env.define("strings", stringsModule)
+	env.define("json", jsonModule)
+	env.define("regex", regexModule)
+	env.define("time", timeModule)
 
 	return &Interpreter{
 		Environment: env,
 		Globals:     env,
 		Locals:      map[Expr]int{},
+		Random:      rand.New(newLockedSource(time.Now().UnixNano())),
+		Args:        []string{},
+		Stdin:       bufio.NewReader(os.Stdin),
+		stdinMu:     &sync.Mutex{},
+		loop:        newEventLoop(),
 	}
 }
 
-func (i *Interpreter) Interpret(stmts []Stmt) {
-	for _, stmt := range stmts {
-		err := i.execute(stmt)
-		if err != nil {
-			lox.runtimeError(err)
-			return
-		}
+// DisableIO removes the file and OS natives, so scripts can't touch
+// anything outside the interpreter.
+func (i *Interpreter) DisableIO() {
+	for _, native := range ioNatives {
+		i.Globals.remove(native.Name)
 	}
 }
 
+// Interpret runs stmts until they finish, fail, exceed the Limits or
+// ctx is done, returning the error if there is one.
+func (i *Interpreter) Interpret(ctx context.Context, stmts []Stmt) *RuntimeError {
+	return i.limited(ctx, func() *RuntimeError {
+		for _, stmt := range stmts {
+			err := i.execute(stmt)
+			if err != nil {
+				return err
+			}
+		}
+
+		// The script isn't finished until its async work is
+		return i.runLoop(nil)
+	})
+}
+
 func (i *Interpreter) resolve(expr Expr, depth int) {
 	i.Locals[expr] = depth
 }
@@ -71,7 +168,30 @@ func (i *Interpreter) VisitVarStmt(stmt *VarStmt) (interface{}, *RuntimeError) {
 		}
 	}
 
-	i.Environment.define(stmt.Name.Lexeme, value)
+	if stmt.IsConst {
+		i.Environment.defineConstant(stmt.Name.Lexeme, value)
+	} else {
+		i.Environment.define(stmt.Name.Lexeme, value)
+	}
+	return nil, nil
+}
+
+func (i *Interpreter) VisitVarPatternStmt(stmt *VarPatternStmt) (interface{}, *RuntimeError) {
+	value, err := i.evaluate(stmt.Initializer)
+	if err != nil {
+		return nil, err
+	}
+
+	matched, err := i.matchPattern(stmt.Pattern, value)
+	if err != nil {
+		return nil, err
+	}
+	if !matched {
+		return nil, &RuntimeError{
+			Token:   patternToken(stmt.Pattern),
+			Message: fmt.Sprintf("Cannot destructure %s, it doesn't match the pattern.", i.stringify(value)),
+		}
+	}
 	return nil, nil
 }
 
@@ -84,6 +204,14 @@ func (i *Interpreter) VisitWhileStmt(stmt *WhileStmt) (interface{}, *RuntimeErro
 	for i.isTruthy(val) {
 		err = i.execute(stmt.Body)
 		if err != nil {
+			if err.IsBreak {
+				return nil, nil
+			}
+			// Limits are checked between statements, which have no
+			// token of their own
+			if err.Token == nil {
+				err.Token = stmt.Keyword
+			}
 			return nil, err
 		}
 		val, err = i.evaluate(stmt.Condition)
@@ -95,11 +223,50 @@ func (i *Interpreter) VisitWhileStmt(stmt *WhileStmt) (interface{}, *RuntimeErro
 	return nil, nil
 }
 
+func (i *Interpreter) VisitForInStmt(stmt *ForInStmt) (interface{}, *RuntimeError) {
+	iterable, err := i.evaluate(stmt.Iterable)
+	if err != nil {
+		return nil, err
+	}
+
+	next, stop, err := i.iterator(iterable, stmt.Keyword)
+	if err != nil {
+		return nil, err
+	}
+	if stop != nil {
+		defer stop()
+	}
+
+	for {
+		value, ok, err := next()
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return nil, nil
+		}
+
+		// A new variable each time round, so closures keep their own
+		environment := NewEnvironment(i.Environment)
+		environment.define(stmt.Name.Lexeme, value)
+		err = i.executeBlock([]Stmt{stmt.Body}, environment)
+		if err != nil {
+			if err.IsBreak {
+				return nil, nil
+			}
+			if err.Token == nil {
+				err.Token = stmt.Keyword
+			}
+			return nil, err
+		}
+	}
+}
+
 func (i *Interpreter) VisitBlockStmt(stmt *BlockStmt) (interface{}, *RuntimeError) {
-	i.executeBlock(
+	err := i.executeBlock(
 		stmt.Statements,
 		NewEnvironment(i.Environment))
-	return nil, nil
+	return nil, err
 }
 
 func (i *Interpreter) VisitIfStmt(stmt *IfStmt) (interface{}, *RuntimeError) {
@@ -124,6 +291,10 @@ func (i *Interpreter) VisitIfStmt(stmt *IfStmt) (interface{}, *RuntimeError) {
 }
 
 func (i *Interpreter) VisitFunctionStmt(stmt *FunctionStmt) (interface{}, *RuntimeError) {
+	if err := i.allocate(stmt.Name); err != nil {
+		return nil, err
+	}
+
 	function := &LoxFunction{
 		Declaration: stmt,
 		Closure:     i.Environment,
@@ -136,6 +307,42 @@ func (i *Interpreter) VisitReturnStmt(stmt *ReturnStmt) (interface{}, *RuntimeEr
 	var value interface{}
 	var err *RuntimeError
 
+	if stmt.TailCall {
+		call := stmt.Value.(*CallExpr)
+		function, arguments, err := i.evaluateCall(call)
+		if err != nil {
+			return nil, err
+		}
+
+		// Only Lox functions run on the trampoline, anything else is
+		// called as usual
+		if loxFunction, ok := function.(*LoxFunction); ok {
+			return nil, &RuntimeError{
+				IsReturn: true,
+				TailCall: &TailCall{Function: loxFunction, Args: arguments, Line: call.Paren.Line},
+			}
+		}
+		value, err = i.call(function, arguments, call.Paren)
+		if err != nil {
+			return nil, err
+		}
+		return nil, &RuntimeError{Return: value, IsReturn: true}
+	}
+
+	if stmt.Value != nil {
+		value, err = i.evaluate(stmt.Value)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return nil, &RuntimeError{Return: value, IsReturn: true}
+}
+
+func (i *Interpreter) VisitYieldStmt(stmt *YieldStmt) (interface{}, *RuntimeError) {
+	var value interface{}
+	var err *RuntimeError
+
 	if stmt.Value != nil {
 		value, err = i.evaluate(stmt.Value)
 		if err != nil {
@@ -143,7 +350,133 @@ func (i *Interpreter) VisitReturnStmt(stmt *ReturnStmt) (interface{}, *RuntimeEr
 		}
 	}
 
-	return nil, &RuntimeError{Return: value}
+	return nil, i.coroutine.yield(value)
+}
+
+func (i *Interpreter) VisitSelectStmt(stmt *SelectStmt) (interface{}, *RuntimeError) {
+	cases := []reflect.SelectCase{}
+	for _, c := range stmt.Cases {
+		val, err := i.evaluate(c.Channel)
+		if err != nil {
+			return nil, err
+		}
+		channel, ok := val.(*LoxChannel)
+		if !ok {
+			return nil, &RuntimeError{
+				Token:   c.Keyword,
+				Message: "Select cases must use a channel.",
+			}
+		}
+
+		if !c.IsSend {
+			cases = append(cases, reflect.SelectCase{
+				Dir:  reflect.SelectRecv,
+				Chan: reflect.ValueOf(channel.ch),
+			})
+			continue
+		}
+
+		value, err := i.evaluate(c.Value)
+		if err != nil {
+			return nil, err
+		}
+		cases = append(cases, reflect.SelectCase{
+			Dir:  reflect.SelectSend,
+			Chan: reflect.ValueOf(channel.ch),
+			// Send as an interface{}, not as value's dynamic type
+			Send: reflect.ValueOf(&value).Elem(),
+		})
+	}
+
+	// Extra cases go after the script's, so their indexes still match
+	if stmt.Default != nil {
+		cases = append(cases, reflect.SelectCase{Dir: reflect.SelectDefault})
+	}
+	if i.budget != nil {
+		cases = append(cases, reflect.SelectCase{
+			Dir:  reflect.SelectRecv,
+			Chan: reflect.ValueOf(i.done()),
+		})
+	}
+
+	chosen, received, err := i.selectCase(stmt, cases)
+	if err != nil {
+		return nil, err
+	}
+
+	environment := NewEnvironment(i.Environment)
+	if chosen == len(stmt.Cases) && stmt.Default != nil {
+		return nil, i.executeBlock(stmt.Default, environment)
+	}
+	if chosen >= len(stmt.Cases) {
+		// The context is done
+		err := i.checkContext()
+		if err != nil {
+			err.Token = stmt.Keyword
+		}
+		return nil, err
+	}
+
+	c := stmt.Cases[chosen]
+	if c.Name != nil {
+		environment.define(c.Name.Lexeme, received)
+	}
+	return nil, i.executeBlock(c.Body, environment)
+}
+
+// selectCase waits for one of cases to be ready. A case receiving from
+// a closed channel receives nil.
+func (i *Interpreter) selectCase(stmt *SelectStmt, cases []reflect.SelectCase) (chosen int, received interface{}, err *RuntimeError) {
+	// Sending on a channel that's closed panics
+	defer func() {
+		if recover() != nil {
+			err = closedChannelError()
+			err.Token = stmt.Keyword
+		}
+	}()
+
+	chosen, value, ok := reflect.Select(cases)
+	if ok && chosen < len(stmt.Cases) {
+		received = value.Interface()
+	}
+	return chosen, received, nil
+}
+
+func (i *Interpreter) VisitSwitchStmt(stmt *SwitchStmt) (interface{}, *RuntimeError) {
+	value, err := i.evaluate(stmt.Value)
+	if err != nil {
+		return nil, err
+	}
+
+	// Case values are evaluated in order, up to the first that matches
+	body := stmt.Default
+	for _, switchCase := range stmt.Cases {
+		matched := false
+		for _, expr := range switchCase.Values {
+			caseValue, err := i.evaluate(expr)
+			if err != nil {
+				return nil, err
+			}
+			if i.isEqual(value, caseValue) {
+				matched = true
+				break
+			}
+		}
+		if matched {
+			body = switchCase.Body
+			break
+		}
+	}
+
+	err = i.executeBlock(body, NewEnvironment(i.Environment))
+	if err != nil && !err.IsBreak {
+		return nil, err
+	}
+	return nil, nil
+}
+
+func (i *Interpreter) VisitBreakStmt(stmt *BreakStmt) (interface{}, *RuntimeError) {
+	return nil, &RuntimeError{Token: stmt.Keyword, IsBreak: true}
 }
 
 func (i *Interpreter) VisitClassStmt(stmt *ClassStmt) (interface{}, *RuntimeError) {
@@ -199,16 +532,41 @@ func (i *Interpreter) VisitClassStmt(stmt *ClassStmt) (interface{}, *RuntimeErro
 // Expressions
 
 func (i *Interpreter) VisitGetExpr(expr *GetExpr) (interface{}, *RuntimeError) {
-	object, err := i.evaluate(expr.Object)
-	if err != nil {
-		return nil, err
+	value, _, err := i.getLink(expr)
+	return value, err
+}
+
+// evaluateLink evaluates expr as the object or callee of a property,
+// call or index, reporting whether a ?. found nil so the rest of the
+// chain is skipped.
+func (i *Interpreter) evaluateLink(expr Expr) (interface{}, bool, *RuntimeError) {
+	switch link := expr.(type) {
+	case *GetExpr:
+		return i.getLink(link)
+	case *CallExpr:
+		return i.callLink(link)
+	case *IndexExpr:
+		return i.indexLink(link)
 	}
+	value, err := i.evaluate(expr)
+	return value, false, err
+}
 
-	if instance, ok := object.(*LoxInstance); ok {
-		return instance.get(expr.Name)
+func (i *Interpreter) getLink(expr *GetExpr) (interface{}, bool, *RuntimeError) {
+	object, skipped, err := i.evaluateLink(expr.Object)
+	if err != nil || skipped {
+		return nil, skipped, err
+	}
+	if object == nil && expr.Optional {
+		return nil, true, nil
 	}
 
-	return nil, &RuntimeError{
+	if obj, ok := object.(LoxObject); ok {
+		value, err := obj.get(expr.Name)
+		return value, false, err
+	}
+
+	return nil, false, &RuntimeError{
 		Token:   expr.Name,
 		Message: "Only instances have properties.",
 	}
@@ -220,7 +578,7 @@ func (i *Interpreter) VisitSetExpr(expr *SetExpr) (interface{}, *RuntimeError) {
 		return nil, err
 	}
 
-	instance, ok := object.(*LoxInstance)
+	obj, ok := object.(LoxObject)
 	if !ok {
 		return nil, &RuntimeError{
 			Token:   expr.Name,
@@ -228,12 +586,155 @@ func (i *Interpreter) VisitSetExpr(expr *SetExpr) (interface{}, *RuntimeError) {
 		}
 	}
 
+	var current interface{}
+	if expr.Operator != nil {
+		current, err = obj.get(expr.Name)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	value, err := i.evaluate(expr.Value)
 	if err != nil {
 		return nil, err
 	}
+	if expr.Operator != nil {
+		value, err = i.binary(expr.Operator, current, value)
+		if err != nil {
+			return nil, err
+		}
+	}
 
-	instance.set(expr.Name, value)
+	err = obj.set(expr.Name, value)
+	if err != nil {
+		return nil, err
+	}
+	if expr.Postfix {
+		return current, nil
+	}
+	return value, nil
+}
+
+func (i *Interpreter) VisitListExpr(expr *ListExpr) (interface{}, *RuntimeError) {
+	elements := []interface{}{}
+	for _, element := range expr.Elements {
+		val, err := i.evaluate(element)
+		if err != nil {
+			return nil, err
+		}
+		elements = append(elements, val)
+	}
+
+	if err := i.allocate(expr.Bracket); err != nil {
+		return nil, err
+	}
+	return &LoxList{Elements: elements}, nil
+}
+
+func (i *Interpreter) VisitMapExpr(expr *MapExpr) (interface{}, *RuntimeError) {
+	m := NewLoxMap()
+	for idx := range expr.Keys {
+		key, err := i.evaluate(expr.Keys[idx])
+		if err != nil {
+			return nil, err
+		}
+		value, err := i.evaluate(expr.Values[idx])
+		if err != nil {
+			return nil, err
+		}
+		m.set(key, value)
+	}
+
+	if err := i.allocate(expr.Brace); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (i *Interpreter) VisitIndexExpr(expr *IndexExpr) (interface{}, *RuntimeError) {
+	value, _, err := i.indexLink(expr)
+	return value, err
+}
+
+func (i *Interpreter) indexLink(expr *IndexExpr) (interface{}, bool, *RuntimeError) {
+	object, skipped, err := i.evaluateLink(expr.Object)
+	if err != nil || skipped {
+		return nil, skipped, err
+	}
+
+	index, err := i.evaluate(expr.Index)
+	if err != nil {
+		return nil, false, err
+	}
+
+	switch collection := object.(type) {
+	case *LoxList:
+		value, err := collection.get(expr.Bracket, index)
+		return value, false, err
+	case *LoxMap:
+		return collection.get(index), false, nil
+	}
+
+	return nil, false, &RuntimeError{
+		Token:   expr.Bracket,
+		Message: "Only lists and maps can be indexed.",
+	}
+}
+
+func (i *Interpreter) VisitIndexSetExpr(expr *IndexSetExpr) (interface{}, *RuntimeError) {
+	object, err := i.evaluate(expr.Object)
+	if err != nil {
+		return nil, err
+	}
+
+	switch object.(type) {
+	case *LoxList, *LoxMap:
+	default:
+		return nil, &RuntimeError{
+			Token:   expr.Bracket,
+			Message: "Only lists and maps can be indexed.",
+		}
+	}
+
+	index, err := i.evaluate(expr.Index)
+	if err != nil {
+		return nil, err
+	}
+
+	var current interface{}
+	if expr.Operator != nil {
+		if list, ok := object.(*LoxList); ok {
+			current, err = list.get(expr.Bracket, index)
+			if err != nil {
+				return nil, err
+			}
+		} else {
+			current = object.(*LoxMap).get(index)
+		}
+	}
+
+	value, err := i.evaluate(expr.Value)
+	if err != nil {
+		return nil, err
+	}
+	if expr.Operator != nil {
+		value, err = i.binary(expr.Operator, current, value)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if list, ok := object.(*LoxList); ok {
+		err = list.set(expr.Bracket, index, value)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		object.(*LoxMap).set(index, value)
+	}
+	if expr.Postfix {
+		return current, nil
+	}
 	return value, nil
 }
 
@@ -257,8 +758,12 @@ func (i *Interpreter) VisitUnaryExpr(expr *UnaryExpr) (interface{}, *RuntimeErro
 
 	switch expr.Operator.Type {
 	case Bang:
-		return i.isTruthy(right), nil
+		return !i.isTruthy(right), nil
 	case Minus:
+		err := i.checkNumberOperand(expr.Operator, right)
+		if err != nil {
+			return nil, err
+		}
 		return -(right.(float64)), nil
 	}
 
@@ -275,37 +780,43 @@ func (i *Interpreter) VisitBinaryExpr(expr *BinaryExpr) (interface{}, *RuntimeEr
 		return nil, err
 	}
 
-	switch expr.Operator.Type {
+	return i.binary(expr.Operator, left, right)
+}
+
+// binary applies a binary operator, for binary expressions and compound
+// assignments.
+func (i *Interpreter) binary(operator *Token, left interface{}, right interface{}) (interface{}, *RuntimeError) {
+	switch operator.Type {
 	case BangEqual:
 		return !i.isEqual(left, right), nil
 	case EqualEqual:
 		return i.isEqual(left, right), nil
 	case Greater:
-		err := i.checkNumberOperands(expr.Operator, left, right)
+		err := i.checkNumberOperands(operator, left, right)
 		if err != nil {
 			return nil, err
 		}
 		return left.(float64) > right.(float64), nil
 	case GreaterEqual:
-		err := i.checkNumberOperands(expr.Operator, left, right)
+		err := i.checkNumberOperands(operator, left, right)
 		if err != nil {
 			return nil, err
 		}
 		return left.(float64) >= right.(float64), nil
 	case Less:
-		err := i.checkNumberOperands(expr.Operator, left, right)
+		err := i.checkNumberOperands(operator, left, right)
 		if err != nil {
 			return nil, err
 		}
 		return left.(float64) < right.(float64), nil
 	case LessEqual:
-		err := i.checkNumberOperands(expr.Operator, left, right)
+		err := i.checkNumberOperands(operator, left, right)
 		if err != nil {
 			return nil, err
 		}
 		return left.(float64) <= right.(float64), nil
 	case Minus:
-		err := i.checkNumberOperand(expr.Operator, right)
+		err := i.checkNumberOperands(operator, left, right)
 		if err != nil {
 			return nil, err
 		}
@@ -322,47 +833,102 @@ func (i *Interpreter) VisitBinaryExpr(expr *BinaryExpr) (interface{}, *RuntimeEr
 		strLeft, isStrLeft := left.(string)
 		strRight, isStrRight := right.(string)
 		if isStrLeft && isStrRight {
-			return strLeft + strRight, nil
+			result := strLeft + strRight
+			if err := i.checkString(operator, result); err != nil {
+				return nil, err
+			}
+			return result, nil
 		}
 
 		msg := "Operands must be two numbers or two strings."
 		return nil, &RuntimeError{
-			Token:   expr.Operator,
+			Token:   operator,
 			Message: msg,
 		}
 	case Slash:
-		err := i.checkNumberOperands(expr.Operator, left, right)
+		err := i.checkNumberOperands(operator, left, right)
 		if err != nil {
 			return nil, err
 		}
 		if right.(float64) == 0 {
 			msg := "Cannot divide by 0."
 			return nil, &RuntimeError{
-				Token:   expr.Operator,
+				Token:   operator,
 				Message: msg,
 			}
 		}
 		return left.(float64) / right.(float64), nil
+	case Percent:
+		err := i.checkNumberOperands(operator, left, right)
+		if err != nil {
+			return nil, err
+		}
+		if right.(float64) == 0 {
+			return nil, &RuntimeError{
+				Token:   operator,
+				Message: "Cannot divide by 0.",
+			}
+		}
+		return math.Mod(left.(float64), right.(float64)), nil
 	case Star:
-		err := i.checkNumberOperands(expr.Operator, left, right)
+		err := i.checkNumberOperands(operator, left, right)
 		if err != nil {
 			return nil, err
 		}
 		return left.(float64) * right.(float64), nil
+	case Is:
+		class, ok := right.(*LoxClass)
+		if !ok {
+			return nil, &RuntimeError{
+				Token:   operator,
+				Message: "Right operand of 'is' must be a class.",
+			}
+		}
+		if instance, ok := left.(*LoxInstance); ok {
+			return instance.Class.isSubclassOf(class), nil
+		}
+		return false, nil
 	}
 
 	return nil, nil
 }
 
+func (i *Interpreter) VisitConditionalExpr(expr *ConditionalExpr) (interface{}, *RuntimeError) {
+	condition, err := i.evaluate(expr.Condition)
+	if err != nil {
+		return nil, err
+	}
+
+	if i.isTruthy(condition) {
+		return i.evaluate(expr.Then)
+	}
+	return i.evaluate(expr.Else)
+}
+
 func (i *Interpreter) VisitVarExpr(expr *VarExpr) (interface{}, *RuntimeError) {
 	return i.lookupVariable(expr.Name, expr)
 }
 
 func (i *Interpreter) VisitAssignExpr(expr *AssignExpr) (interface{}, *RuntimeError) {
+	var current interface{}
+	var err *RuntimeError
+	if expr.Operator != nil {
+		current, err = i.lookupVariable(expr.Name, expr)
+		if err != nil {
+			return nil, err
+		}
+	}
+
 	value, err := i.evaluate(expr.Value)
 	if err != nil {
 		return nil, err
 	}
+	if expr.Operator != nil {
+		value, err = i.binary(expr.Operator, current, value)
+		if err != nil {
+			return nil, err
+		}
+	}
 
 	if distance, ok := i.Locals[expr]; ok {
 		i.Environment.assignAt(distance, expr.Name, value)
@@ -373,6 +939,9 @@ func (i *Interpreter) VisitAssignExpr(expr *AssignExpr) (interface{}, *RuntimeEr
 		return nil, err
 	}
 
+	if expr.Postfix {
+		return current, nil
+	}
 	return value, nil
 }
 
@@ -386,6 +955,10 @@ func (i *Interpreter) VisitLogicalExpr(expr *LogicalExpr) (interface{}, *Runtime
 		if i.isTruthy(left) {
 			return left, nil
 		}
+	} else if expr.Operator.Type == QuestionQuestion {
+		if left != nil {
+			return left, nil
+		}
 	} else {
 		if !i.isTruthy(left) {
 			return left, nil
@@ -396,16 +969,316 @@ func (i *Interpreter) VisitLogicalExpr(expr *LogicalExpr) (interface{}, *Runtime
 }
 
 func (i *Interpreter) VisitCallExpr(expr *CallExpr) (interface{}, *RuntimeError) {
-	callee, err := i.evaluate(expr.Callee)
+	value, _, err := i.callLink(expr)
+	return value, err
+}
+
+func (i *Interpreter) callLink(expr *CallExpr) (interface{}, bool, *RuntimeError) {
+	function, arguments, err := i.evaluateCall(expr)
+	if err != nil || function == nil {
+		return nil, err == nil, err
+	}
+	value, err := i.call(function, arguments, expr.Paren)
+	return value, false, err
+}
+
+func (i *Interpreter) VisitSpawnExpr(expr *SpawnExpr) (interface{}, *RuntimeError) {
+	// The callee and args are evaluated before the task starts, so only
+	// the call itself runs on the task
+	function, arguments, err := i.evaluateCall(expr.Call)
+	if err != nil || function == nil {
+		return nil, err
+	}
+	return i.spawn(function, arguments, expr.Call.Paren), nil
+}
+
+func (i *Interpreter) VisitMatchExpr(expr *MatchExpr) (interface{}, *RuntimeError) {
+	value, err := i.evaluate(expr.Value)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, arm := range expr.Arms {
+		result, matched, err := i.matchArm(arm, value)
+		if err != nil || matched {
+			return result, err
+		}
+	}
+
+	return nil, &RuntimeError{
+		Token:   expr.Keyword,
+		Message: fmt.Sprintf("No match arm matches %s.", i.stringify(value)),
+	}
+}
+
+// matchArm evaluates arm's body if value matches it, in a new
+// environment holding the pattern's bindings.
+func (i *Interpreter) matchArm(arm *MatchArm, value interface{}) (interface{}, bool, *RuntimeError) {
+	previousEnv := i.Environment
+	i.Environment = NewEnvironment(previousEnv)
+	defer func() {
+		i.Environment = previousEnv
+	}()
+
+	matched, err := i.matchPattern(arm.Pattern, value)
+	if err != nil || !matched {
+		return nil, false, err
+	}
+
+	if arm.Guard != nil {
+		guard, err := i.evaluate(arm.Guard)
+		if err != nil || !i.isTruthy(guard) {
+			return nil, false, err
+		}
+	}
+
+	result, err := i.evaluate(arm.Body)
+	return result, true, err
+}
+
+// matchPattern reports whether value matches pattern, binding names in
+// the current environment as it goes.
+func (i *Interpreter) matchPattern(pattern Pattern, value interface{}) (bool, *RuntimeError) {
+	switch p := pattern.(type) {
+	case *LiteralPattern:
+		return i.isEqual(p.Value, value), nil
+	case *WildcardPattern:
+		return true, nil
+	case *BindingPattern:
+		i.Environment.define(p.Name.Lexeme, value)
+		return true, nil
+	case *AlternativePattern:
+		for _, alternative := range p.Alternatives {
+			matched, err := i.matchPattern(alternative, value)
+			if err != nil || matched {
+				return matched, err
+			}
+		}
+		return false, nil
+	case *ClassPattern:
+		return i.matchClassPattern(p, value)
+	case *ObjectPattern:
+		return i.matchObjectPattern(p, value)
+	case *ListPattern:
+		return i.matchListPattern(p, value)
+	}
+	return false, nil
+}
+
+// matchObjectPattern matches instances by field name and maps by
+// string key, so fields never match keys like 1 or true. Nothing else
+// matches, even with no fields.
+func (i *Interpreter) matchObjectPattern(pattern *ObjectPattern, value interface{}) (bool, *RuntimeError) {
+	switch value.(type) {
+	case *LoxInstance, *LoxMap:
+	default:
+		return false, nil
+	}
+
+	for _, field := range pattern.Fields {
+		var fieldValue interface{}
+		var ok bool
+		switch object := value.(type) {
+		case *LoxInstance:
+			fieldValue, ok = object.field(field.Name.Lexeme)
+		case *LoxMap:
+			fieldValue, ok = object.lookup(field.Name.Lexeme)
+		}
+		if !ok {
+			return false, nil
+		}
+
+		matched, err := i.matchPattern(field.Pattern, fieldValue)
+		if err != nil || !matched {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+func (i *Interpreter) matchClassPattern(pattern *ClassPattern, value interface{}) (bool, *RuntimeError) {
+	callee, err := i.evaluate(pattern.Class)
+	if err != nil {
+		return false, err
+	}
+	class, ok := callee.(*LoxClass)
+	if !ok {
+		return false, &RuntimeError{
+			Token:   pattern.Class.Name,
+			Message: fmt.Sprintf("'%s' in a pattern must be a class.", pattern.Class.Name.Lexeme),
+		}
+	}
+
+	instance, ok := value.(*LoxInstance)
+	if !ok || !instance.Class.isSubclassOf(class) {
+		return false, nil
+	}
+
+	for _, field := range pattern.Fields {
+		fieldValue, ok := instance.field(field.Name.Lexeme)
+		if !ok {
+			return false, nil
+		}
+		matched, err := i.matchPattern(field.Pattern, fieldValue)
+		if err != nil || !matched {
+			return false, err
+		}
+	}
+	return true, nil
+}
+
+func (i *Interpreter) matchListPattern(pattern *ListPattern, value interface{}) (bool, *RuntimeError) {
+	list, ok := value.(*LoxList)
+	if !ok {
+		return false, nil
+	}
+
+	elements := list.elements()
+	count := len(pattern.Elements)
+	if len(elements) < count || (pattern.Rest == nil && len(elements) != count) {
+		return false, nil
+	}
+
+	for idx, element := range pattern.Elements {
+		matched, err := i.matchPattern(element, elements[idx])
+		if err != nil || !matched {
+			return false, err
+		}
+	}
+
+	if pattern.Rest != nil {
+		if err := i.allocate(pattern.Bracket); err != nil {
+			return false, err
+		}
+		rest := elements[count:]
+		i.Environment.define(pattern.Rest.Lexeme, &LoxList{Elements: rest})
+	}
+	return true, nil
+}
+
+func (i *Interpreter) VisitDestructureExpr(expr *DestructureExpr) (interface{}, *RuntimeError) {
+	value, err := i.evaluate(expr.Value)
+	if err != nil {
+		return nil, err
+	}
+
+	err = i.destructure(expr.Bracket, expr.Targets, value)
+	if err != nil {
+		return nil, err
+	}
+	return value, nil
+}
+
+// destructure assigns the elements of value, which must be a list of
+// the same length, to targets.
+func (i *Interpreter) destructure(bracket *Token, targets []Expr, value interface{}) *RuntimeError {
+	list, ok := value.(*LoxList)
+	var elements []interface{}
+	if ok {
+		// Copy first, in case the targets are elements of the list itself
+		elements = list.elements()
+	}
+	if !ok || len(elements) != len(targets) {
+		return &RuntimeError{
+			Token:   bracket,
+			Message: fmt.Sprintf("Cannot destructure %s into %d targets.", i.stringify(value), len(targets)),
+		}
+	}
+
+	for idx, target := range targets {
+		if err := i.assignTarget(target, elements[idx]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (i *Interpreter) assignTarget(target Expr, value interface{}) *RuntimeError {
+	switch t := target.(type) {
+	case *VarExpr:
+		if distance, ok := i.Locals[t]; ok {
+			return i.Environment.assignAt(distance, t.Name, value)
+		}
+		return i.Globals.assign(t.Name, value)
+	case *GetExpr:
+		object, err := i.evaluate(t.Object)
+		if err != nil {
+			return err
+		}
+		obj, ok := object.(LoxObject)
+		if !ok {
+			return &RuntimeError{
+				Token:   t.Name,
+				Message: "Only instances have properties.",
+			}
+		}
+		return obj.set(t.Name, value)
+	case *IndexExpr:
+		object, err := i.evaluate(t.Object)
+		if err != nil {
+			return err
+		}
+		index, err := i.evaluate(t.Index)
+		if err != nil {
+			return err
+		}
+		switch o := object.(type) {
+		case *LoxList:
+			return o.set(t.Bracket, index, value)
+		case *LoxMap:
+			o.set(index, value)
+			return nil
+		}
+		return &RuntimeError{
+			Token:   t.Bracket,
+			Message: "Only lists and maps can be indexed.",
+		}
+	case *ListExpr:
+		return i.destructure(t.Bracket, t.Elements, value)
+	}
+	return nil
+}
+
+func (i *Interpreter) VisitAwaitExpr(expr *AwaitExpr) (interface{}, *RuntimeError) {
+	value, err := i.evaluate(expr.Value)
 	if err != nil {
 		return nil, err
 	}
+	promise, ok := value.(*LoxPromise)
+	if !ok {
+		return value, nil
+	}
+
+	// Async bodies give way to the event loop until it's settled, but
+	// top-level code has to run the loop itself
+	if i.coroutine != nil {
+		value, err = i.coroutine.await(promise)
+	} else if err = i.runLoop(promise); err == nil {
+		value, err = promise.result()
+	}
+	if err != nil && err.Token == nil {
+		err.Token = expr.Keyword
+	}
+	return value, err
+}
+
+// evaluateCall evaluates the callee and arguments of a call, and checks
+// they fit.
+func (i *Interpreter) evaluateCall(expr *CallExpr) (LoxCallable, []interface{}, *RuntimeError) {
+	callee, skipped, err := i.evaluateLink(expr.Callee)
+	if err != nil {
+		return nil, nil, err
+	}
+	// Callers skip the call when a ?. skipped the callee
+	if skipped {
+		return nil, nil, nil
+	}
 
 	arguments := []interface{}{}
 	for _, arg := range expr.Arguments {
 		res, err := i.evaluate(arg)
 		if err != nil {
-			return nil, err
+			return nil, nil, err
 		}
 		arguments = append(arguments, res)
 	}
@@ -413,22 +1286,138 @@ func (i *Interpreter) VisitCallExpr(expr *CallExpr) (interface{}, *RuntimeError)
 	// Cast as callable
 	function, ok := callee.(LoxCallable)
 	if !ok {
-		return nil, &RuntimeError{
+		return nil, nil, &RuntimeError{
 			Token:   expr.Paren,
 			Message: "Can only call functions and classes.",
 		}
 	}
 
+	// Named args
+	for _, name := range expr.Names {
+		if name != nil {
+			arguments, err = i.bindNamedArguments(function, expr, arguments)
+			if err != nil {
+				return nil, nil, err
+			}
+			break
+		}
+	}
+
 	// Check arity
-	if len(arguments) != function.Arity() {
-		msg := fmt.Sprintf("Expected %d arguments but got %d.", function.Arity(), len(arguments))
-		return nil, &RuntimeError{
+	arity := function.Arity()
+	if !arity.accepts(len(arguments)) {
+		msg := fmt.Sprintf("Expected %s arguments but got %d.", arity, len(arguments))
+		return nil, nil, &RuntimeError{
 			Token:   expr.Paren,
 			Message: msg,
 		}
 	}
 
-	return function.Call(i, arguments)
+	return function, arguments, nil
+}
+
+// call calls function in a new stack frame, paren being the call site.
+// Calls from Go or the event loop have no call site, so no paren.
+func (i *Interpreter) call(function LoxCallable, arguments []interface{}, paren *Token) (interface{}, *RuntimeError) {
+	if err := i.checkCallDepth(paren); err != nil {
+		return nil, err
+	}
+	frame := &CallFrame{Name: frameName(function)}
+	if paren != nil {
+		frame.Line = paren.Line
+	}
+	i.Frames = append(i.Frames, frame)
+	defer func() {
+		i.Frames = i.Frames[:len(i.Frames)-1]
+	}()
+
+	result, err := function.Call(i, arguments)
+	if err != nil {
+		// Natives don't know where they were called from
+		if err.Token == nil {
+			err.Token = paren
+		}
+		// Capture the stack where the error happened, before it unwinds
+		if err.Trace == nil {
+			err.Trace = append([]*CallFrame{}, i.Frames...)
+		}
+		return nil, err
+	}
+
+	// Natives build strings too
+	if err := i.checkString(paren, result); err != nil {
+		return nil, err
+	}
+
+	return result, nil
+}
+
+// bindNamedArguments moves named args into the position of the param
+// they name, leaving missingArgument in any gaps.
+func (i *Interpreter) bindNamedArguments(function LoxCallable, expr *CallExpr, args []interface{}) ([]interface{}, *RuntimeError) {
+	var declaration *FunctionStmt
+	switch callee := function.(type) {
+	case *LoxFunction:
+		declaration = callee.Declaration
+	case *LoxClass:
+		if initializer, ok := callee.findMethod("init"); ok {
+			declaration = initializer.Declaration
+		}
+	}
+	if declaration == nil {
+		return nil, &RuntimeError{
+			Token:   expr.Paren,
+			Message: "Named arguments can only be passed to Lox functions.",
+		}
+	}
+
+	bound := []interface{}{}
+	for idx, arg := range args {
+		if expr.Names[idx] == nil {
+			bound = append(bound, arg)
+		}
+	}
+
+	for idx, name := range expr.Names {
+		if name == nil {
+			continue
+		}
+
+		position := -1
+		for p, param := range declaration.Params {
+			if param.Lexeme == name.Lexeme {
+				position = p
+			}
+		}
+		if position == -1 {
+			return nil, &RuntimeError{
+				Token:   name,
+				Message: fmt.Sprintf("Unknown parameter '%s'.", name.Lexeme),
+			}
+		}
+
+		for len(bound) <= position {
+			bound = append(bound, missingArgument)
+		}
+		if bound[position] != missingArgument {
+			return nil, &RuntimeError{
+				Token:   name,
+				Message: fmt.Sprintf("Argument for parameter '%s' passed more than once.", name.Lexeme),
+			}
+		}
+		bound[position] = args[idx]
+	}
+
+	for p, arg := range bound {
+		if arg == missingArgument && declaration.Defaults[p] == nil {
+			return nil, &RuntimeError{
+				Token:   expr.Paren,
+				Message: fmt.Sprintf("Missing argument for parameter '%s'.", declaration.Params[p].Lexeme),
+			}
+		}
+	}
+
+	return bound, nil
 }
 
 func (i *Interpreter) VisitThisExpr(expr *ThisExpr) (interface{}, *RuntimeError) {
@@ -462,6 +1451,10 @@ func (i *Interpreter) VisitSuperExpr(expr *SuperExpr) (interface{}, *RuntimeErro
 // Helpers
 
 func (i *Interpreter) execute(stmt Stmt) *RuntimeError {
+	if err := i.step(); err != nil {
+		return err
+	}
+
 	_, err := stmt.Accept(i)
 	return err
 }
@@ -483,10 +1476,52 @@ func (i *Interpreter) executeBlock(statements []Stmt, env *Environment) *Runtime
 	return nil
 }
 
+// fork returns a copy of the interpreter to run code on another
+// goroutine. It shares globals and limits, but has its own call state.
+func (i *Interpreter) fork() *Interpreter {
+	fork := *i
+	fork.Environment = i.Globals
+	fork.Frames = nil
+	fork.coroutine = nil
+	return &fork
+}
+
+// done returns a channel that's closed once the script's context is
+// done, or nil when it has none.
+func (i *Interpreter) done() <-chan struct{} {
+	if i.budget == nil {
+		return nil
+	}
+	return i.budget.ctx.Done()
+}
+
 func (i *Interpreter) evaluate(expr Expr) (interface{}, *RuntimeError) {
 	return expr.Accept(i)
 }
 
+func (i *Interpreter) evaluateIn(expr Expr, env *Environment) (interface{}, *RuntimeError) {
+	previousEnv := i.Environment
+	i.Environment = env
+	defer func() {
+		i.Environment = previousEnv
+	}()
+
+	return i.evaluate(expr)
+}
+
+func frameName(function LoxCallable) string {
+	switch callee := function.(type) {
+	case *LoxFunction:
+		return callee.Declaration.Name.Lexeme + "()"
+	case *LoxClass:
+		return callee.Name + ".init()"
+	case *NativeFunction:
+		return callee.Name + "()"
+	default:
+		return "native fn"
+	}
+}
+
 func (i *Interpreter) isTruthy(val interface{}) bool {
 	if val == nil {
 		return false
@@ -532,6 +1567,12 @@ func (i *Interpreter) checkNumberOperands(operator *Token, left interface{}, rig
 }
 
 func (i *Interpreter) stringify(val interface{}) string {
+	return i.stringifyNested(val, map[interface{}]bool{})
+}
+
+// stringifyNested stringifies val inside the lists and maps in
+// visiting, writing [...] or {...} for one that contains itself.
+func (i *Interpreter) stringifyNested(val interface{}, visiting map[interface{}]bool) string {
 	if val == nil {
 		return "nil"
 	}
@@ -541,5 +1582,33 @@ func (i *Interpreter) stringify(val interface{}) string {
 		return sVal
 	}
 
+	if list, ok := val.(*LoxList); ok {
+		if visiting[list] {
+			return "[...]"
+		}
+		visiting[list] = true
+		defer delete(visiting, list)
+
+		elements := []string{}
+		for _, element := range list.elements() {
+			elements = append(elements, i.stringifyNested(element, visiting))
+		}
+		return "[" + strings.Join(elements, ", ") + "]"
+	}
+
+	if m, ok := val.(*LoxMap); ok {
+		if visiting[m] {
+			return "{...}"
+		}
+		visiting[m] = true
+		defer delete(visiting, m)
+
+		entries := []string{}
+		for _, key := range m.keys() {
+			entries = append(entries, i.stringifyNested(key, visiting)+": "+i.stringifyNested(m.get(key), visiting))
+		}
+		return "{" + strings.Join(entries, ", ") + "}"
+	}
+
 	return fmt.Sprintf("%v", val)
 }
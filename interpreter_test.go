@@ -0,0 +1,69 @@
+package glox_test
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/alexmarchant/glox"
+)
+
+// Scripts that should fail with an error rather than crash or
+// carry on.
+func TestRuntimeErrors(t *testing.T) {
+	tests := []struct {
+		source  string
+		message string
+	}{
+		{`json.stringify([1], -1);`, "Argument 2 to json.stringify() must be an indent of at least 0."},
+		{`-"a";`, "Operand must be number."},
+		{`"a" - 1;`, "Operands must be numbers."},
+		{`1 - nil;`, "Operands must be numbers."},
+		{`strings.repeat("ab", 4611686018427387904);`, "Repeat count is too large."},
+		{`math.randomInt(-4611686018427387904, 4611686018427387904);`, "Invalid range -4611686018427387904 to 4611686018427387904 for math.randomInt()."},
+		{`sqrt(4);`, "Undefined variable 'sqrt'."},
+		{`upper("a");`, "Undefined variable 'upper'."},
+		{`strings.upper(1);`, "Argument 1 to strings.upper() must be a string."},
+		{`switch (1) { case -1: print(1); case 2, -1: print(2); }`, "Duplicate case value."},
+		{`fun f() { f(); } f();`, "Stack overflow, call depth limit of 10000 exceeded."},
+		{`var {} = 5;`, "Cannot destructure 5.000000, it doesn't match the pattern."},
+		{`var {x} = {1: 2};`, "Cannot destructure {1.000000: 2.000000}, it doesn't match the pattern."},
+		// Only a ?. skips the rest of a chain, a plain . doesn't
+		{`class A {} var a = A(); a.b = nil; a?.b.c;`, "Only instances have properties."},
+		{`var a = nil; a?.b = 1;`, "Invalid assignment target."},
+		{`var a = nil; a?.b[0] = 1;`, "Invalid assignment target."},
+		{`var a = nil; a?.b++;`, "Invalid assignment target."},
+		{`var a = nil; [a?.b] = [1];`, "Invalid assignment target."},
+		// Only a conditional's ?. reads .5 as a number
+		{`print(.5);`, "Exprected expression."},
+		{`class A { *init() {} }`, "Initializer cannot be a generator."},
+	}
+
+	for _, test := range tests {
+		t.Run(test.source, func(t *testing.T) {
+			lox, _ := newLox()
+			runError(t, lox, test.source, test.message)
+		})
+	}
+}
+
+func TestStackOverflowTrace(t *testing.T) {
+	lox, stderr := newLox()
+	err := runError(t, lox, "fun f() {\n  f();\n}\nf();", "Stack overflow")
+
+	runtimeErr, ok := err.(*glox.RuntimeError)
+	if !ok || len(runtimeErr.Trace) != glox.DefaultMaxCallDepth {
+		t.Fatalf("error = %#v, want a trace of %d frames", err, glox.DefaultMaxCallDepth)
+	}
+
+	// Only the ends of the trace are printed, around a count of the
+	// rest
+	lines := strings.Split(strings.TrimSpace(stderr.String()), "\n")
+	if len(lines) != 23 {
+		t.Errorf("printed %d lines, want 23:\n%s", len(lines), stderr)
+	}
+	skipped := fmt.Sprintf("... %d more frames", glox.DefaultMaxCallDepth-20)
+	if lines[11] != skipped || lines[22] != "[line 4] in script" {
+		t.Errorf("trace = %q, want %q in the middle and the script last", lines, skipped)
+	}
+}
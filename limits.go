@@ -0,0 +1,190 @@
+package glox
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// Limits bounds what a script can use, so untrusted scripts can't hang
+// or exhaust the host. Zero means unlimited.
+type Limits struct {
+	// Statements executed
+	MaxSteps int
+	// Wall time, on top of any deadline of the context
+	Timeout time.Duration
+	// Calls in progress at once. Zero means DefaultMaxCallDepth, since
+	// there's always a limit before Go's own stack runs out
+	MaxCallDepth int
+	// Instances, lists, maps and closures created
+	MaxAllocations int
+	// Bytes in a string built by the script
+	MaxStringSize int
+}
+
+// DefaultMaxCallDepth leaves plenty of room below Go's stack limit,
+// which recursion reaches at around 100000 calls.
+const DefaultMaxCallDepth = 10000
+
+// How many steps go by between checks of the context.
+const contextCheckInterval = 256
+
+// budget tracks a run's use of the Limits. Interpreters running parts
+// of the same script, like generators and tasks, share it.
+type budget struct {
+	// Counted atomically, and first so they're aligned for it
+	steps       int64
+	allocations int64
+	ctx         context.Context
+
+	// Once a limit is hit nothing more runs, even if a native
+	// swallowed the error
+	failed int32
+	mu     sync.Mutex
+	err    *RuntimeError
+	// Spawned during the run, see failedTask
+	tasks []*LoxTask
+}
+
+// failure returns the limit error the run stopped with, if it has.
+// Each caller gets its own copy, to fill in its token and trace.
+func (b *budget) failure() *RuntimeError {
+	if atomic.LoadInt32(&b.failed) == 0 {
+		return nil
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	err := *b.err
+	return &err
+}
+
+func (b *budget) fail(err *RuntimeError) *RuntimeError {
+	b.mu.Lock()
+	if b.err == nil {
+		b.err = err
+		atomic.StoreInt32(&b.failed, 1)
+	}
+	b.mu.Unlock()
+	return b.failure()
+}
+
+// limited runs fn with a fresh budget under ctx. Runs nested in another
+// one, like a Go callback calling back into Lox, share its budget.
+func (i *Interpreter) limited(ctx context.Context, fn func() *RuntimeError) *RuntimeError {
+	if i.budget != nil {
+		return fn()
+	}
+
+	if i.Limits.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, i.Limits.Timeout)
+		defer cancel()
+	}
+
+	i.budget = &budget{ctx: ctx}
+	defer func() {
+		i.budget = nil
+	}()
+
+	if err := fn(); err != nil {
+		return err
+	}
+	return i.budget.failedTask()
+}
+
+// step counts a statement against the budget.
+func (i *Interpreter) step() *RuntimeError {
+	if i.budget == nil {
+		return nil
+	}
+	if err := i.budget.failure(); err != nil {
+		return err
+	}
+
+	steps := atomic.AddInt64(&i.budget.steps, 1)
+	if i.Limits.MaxSteps > 0 && steps > int64(i.Limits.MaxSteps) {
+		return i.exceeded(nil, fmt.Sprintf("Execution step limit of %d exceeded.", i.Limits.MaxSteps))
+	}
+
+	if steps%contextCheckInterval == 0 {
+		return i.checkContext()
+	}
+
+	return nil
+}
+
+// checkContext stops the script once its context is done.
+func (i *Interpreter) checkContext() *RuntimeError {
+	if i.budget == nil {
+		return nil
+	}
+
+	switch i.budget.ctx.Err() {
+	case context.DeadlineExceeded:
+		return i.exceeded(nil, "Execution timed out.")
+	case context.Canceled:
+		return i.exceeded(nil, "Execution cancelled.")
+	}
+	return nil
+}
+
+// allocate counts a new instance, list, map or closure.
+func (i *Interpreter) allocate(token *Token) *RuntimeError {
+	if i.budget == nil {
+		return nil
+	}
+	if err := i.budget.failure(); err != nil {
+		return err
+	}
+
+	allocations := atomic.AddInt64(&i.budget.allocations, 1)
+	if i.Limits.MaxAllocations > 0 && allocations > int64(i.Limits.MaxAllocations) {
+		return i.exceeded(token, fmt.Sprintf("Allocation limit of %d exceeded.", i.Limits.MaxAllocations))
+	}
+	return nil
+}
+
+// checkString checks a string the script built against the size limit.
+func (i *Interpreter) checkString(token *Token, val interface{}) *RuntimeError {
+	str, ok := val.(string)
+	if !ok {
+		return nil
+	}
+	return i.checkStringSize(token, len(str))
+}
+
+// checkStringSize checks the size of a string before it's built, so
+// natives don't allocate more than the limit first.
+func (i *Interpreter) checkStringSize(token *Token, size int) *RuntimeError {
+	if i.Limits.MaxStringSize <= 0 || size <= i.Limits.MaxStringSize {
+		return nil
+	}
+	return i.exceeded(token, fmt.Sprintf("String size limit of %d exceeded.", i.Limits.MaxStringSize))
+}
+
+// checkCallDepth makes sure there's room for another call.
+func (i *Interpreter) checkCallDepth(token *Token) *RuntimeError {
+	maxDepth := i.Limits.MaxCallDepth
+	if maxDepth <= 0 {
+		maxDepth = DefaultMaxCallDepth
+	}
+	if len(i.Frames) >= maxDepth {
+		return i.exceeded(token, fmt.Sprintf("Stack overflow, call depth limit of %d exceeded.", maxDepth))
+	}
+	return nil
+}
+
+func (i *Interpreter) exceeded(token *Token, message string) *RuntimeError {
+	err := &RuntimeError{
+		Token:   token,
+		Message: message,
+		IsLimit: true,
+	}
+	if i.budget == nil {
+		return err
+	}
+	return i.budget.fail(err)
+}
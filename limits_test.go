@@ -0,0 +1,142 @@
+package glox_test
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/alexmarchant/glox"
+)
+
+func TestLimits(t *testing.T) {
+	tests := []struct {
+		name    string
+		limits  glox.Limits
+		source  string
+		message string
+	}{
+		{
+			"steps",
+			glox.Limits{MaxSteps: 100},
+			"while (true) {}",
+			"Execution step limit of 100 exceeded.",
+		},
+		{
+			"timeout",
+			glox.Limits{Timeout: 10 * time.Millisecond},
+			"while (true) {}",
+			"Execution timed out.",
+		},
+		{
+			"call depth",
+			glox.Limits{MaxCallDepth: 50},
+			"fun f(n) { return 1 + f(n + 1); } f(0);",
+			"Stack overflow, call depth limit of 50 exceeded.",
+		},
+		{
+			"allocations",
+			glox.Limits{MaxAllocations: 10},
+			"var all = nil; while (true) { all = [all]; }",
+			"Allocation limit of 10 exceeded.",
+		},
+		{
+			"string size",
+			glox.Limits{MaxStringSize: 100},
+			`var s = "a"; while (true) { s = s + s; }`,
+			"String size limit of 100 exceeded.",
+		},
+		// Natives check sizes before building strings this big
+		{
+			"repeat",
+			glox.Limits{MaxStringSize: 100},
+			`strings.repeat("ab", 1000000000000);`,
+			"String size limit of 100 exceeded.",
+		},
+		{
+			"format width",
+			glox.Limits{MaxStringSize: 100},
+			`strings.format("%999999d", 1);`,
+			"String size limit of 100 exceeded.",
+		},
+		{
+			"json indent",
+			glox.Limits{MaxStringSize: 100},
+			`json.stringify([1], 1000000000000);`,
+			"String size limit of 100 exceeded.",
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			lox, _ := newLox()
+			lox.Interpreter.Limits = test.limits
+			err := runError(t, lox, test.source, test.message)
+
+			runtimeErr, ok := err.(*glox.RuntimeError)
+			if !ok || !runtimeErr.IsLimit {
+				t.Errorf("error %v isn't a limit error", err)
+			}
+		})
+	}
+}
+
+func TestLimitsApplyPerRun(t *testing.T) {
+	lox, _ := newLox()
+	lox.Interpreter.Limits = glox.Limits{MaxSteps: 50}
+	source := "var n = 0; while (n < 10) { n = n + 1; }"
+
+	// Each run gets a fresh budget
+	for idx := 0; idx < 3; idx++ {
+		run(t, lox, source)
+	}
+}
+
+func TestContextCancel(t *testing.T) {
+	lox, _ := newLox()
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		cancel()
+	}()
+
+	err := lox.Run(ctx, "while (true) {}")
+	if err == nil || !strings.Contains(err.Error(), "Execution cancelled.") {
+		t.Errorf("Run error = %v, want cancellation", err)
+	}
+}
+
+func TestContextCancelWhileWaiting(t *testing.T) {
+	lox, _ := newLox()
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	// Blocked on a channel nothing sends to
+	err := lox.Run(ctx, "Channel().recv();")
+	if err == nil || !strings.Contains(err.Error(), "Execution timed out.") {
+		t.Errorf("Run error = %v, want timeout", err)
+	}
+}
+
+func TestCallContext(t *testing.T) {
+	lox, _ := newLox()
+	lox.Interpreter.Limits = glox.Limits{MaxSteps: 1000}
+	run(t, lox, "fun spin() { while (true) {} }")
+
+	handle, err := lox.Interpreter.Callable("spin")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = handle.Call()
+	if err == nil || !strings.Contains(err.Error(), "Execution step limit of 1000 exceeded.") {
+		t.Errorf("Call error = %v, want step limit", err)
+	}
+
+	lox.Interpreter.Limits = glox.Limits{}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err = handle.CallContext(ctx)
+	if err == nil || !strings.Contains(err.Error(), "Execution cancelled.") {
+		t.Errorf("CallContext error = %v, want cancellation", err)
+	}
+}
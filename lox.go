@@ -1,75 +1,78 @@
-package main
+package glox
 
 import (
-	"bufio"
+	"context"
 	"fmt"
-	"io/ioutil"
-	"log"
+	"io"
 	"os"
 	"strings"
 )
 
+// Lox runs scripts on its Interpreter, reporting their errors.
 type Lox struct {
-	Interpreter     *Interpreter
+	Interpreter *Interpreter
+	// Where errors and warnings are reported
+	Stderr          io.Writer
 	HadError        bool
 	HadRuntimeError bool
+
+	// Reported by the Compile in progress
+	errors []string
 }
 
 func NewLox() *Lox {
-
 	return &Lox{
 		Interpreter: NewInterpreter(),
+		Stderr:      os.Stderr,
 	}
 }
 
-func (l *Lox) runFile(path string) {
-	bytes, err := ioutil.ReadFile(path)
-	if err != nil {
-		log.Fatal(err)
-	}
-	source := string(bytes)
-	l.run(source)
-
-	if l.HadError {
-		os.Exit(65)
-	}
+// CompileError lists the errors that kept a script from running.
+type CompileError struct {
+	Messages []string
+}
 
-	if l.HadRuntimeError {
-		os.Exit(70)
-	}
+func (e *CompileError) Error() string {
+	return strings.Join(e.Messages, "\n")
 }
 
-func (l *Lox) runPrompt() {
-	reader := bufio.NewReader(os.Stdin)
+// Run compiles and interprets source under ctx, reporting and returning
+// the first error. Scripts calling exit() return a *RuntimeError with
+// IsExit set, which isn't reported.
+func (l *Lox) Run(ctx context.Context, source string) error {
+	statements, err := l.Compile(source)
+	if err != nil {
+		return err
+	}
 
-	for {
-		fmt.Print("-> ")
-		text, _ := reader.ReadString('\n')
-		// convert CRLF to LF
-		text = strings.Replace(text, "\n", "", -1)
-		l.run(text)
-		l.resetErrorState()
+	if err := l.Interpreter.Interpret(ctx, statements); err != nil {
+		if !err.IsExit {
+			l.runtimeError(err)
+		}
+		return err
 	}
+	return nil
 }
 
-func (l *Lox) run(source string) {
-	scanner := makeScanner(source)
+// Compile scans, parses and resolves source for the Interpreter to
+// run, returning a *CompileError if anything's wrong with it.
+func (l *Lox) Compile(source string) ([]Stmt, error) {
+	l.errors = nil
+
+	scanner := makeScanner(source, l)
 	tokens := scanner.scanTokens()
-	parser := &Parser{Tokens: tokens}
+	parser := &Parser{Tokens: tokens, Lox: l}
 	statements := parser.parse()
 
-	if lox.HadError {
-		return
+	if len(l.errors) == 0 {
+		resolver := NewResolver(l.Interpreter, l)
+		resolver.resolveStatements(statements)
 	}
 
-	resolver := NewResolver(l.Interpreter)
-	resolver.resolveStatements(statements)
-
-	if lox.HadError {
-		return
+	if len(l.errors) > 0 {
+		return nil, &CompileError{Messages: l.errors}
 	}
-
-	l.Interpreter.Interpret(statements)
+	return statements, nil
 }
 
 func (l *Lox) errorLine(line int, message string) {
@@ -84,19 +87,65 @@ func (l *Lox) errorToken(token *Token, message string) {
 	}
 }
 
+// warn reports something that's probably a mistake, but still runs.
+func (l *Lox) warn(token *Token, message string) {
+	msg := fmt.Sprintf("[line %d] Warning at '%s' : %s", token.Line, token.Lexeme, message)
+	fmt.Fprintln(l.Stderr, msg)
+}
+
 func (l *Lox) report(line int, where string, message string) {
 	msg := fmt.Sprintf("[line %d] Error%s : %s", line, where, message)
-	fmt.Fprintln(os.Stderr, msg)
+	fmt.Fprintln(l.Stderr, msg)
+	l.errors = append(l.errors, msg)
 	l.HadError = true
 }
 
+// How many frames runtimeError shows at each end of a long trace.
+const traceEdgeFrames = 10
+
 func (l *Lox) runtimeError(err *RuntimeError) {
-	msg := fmt.Sprintf("%s\n[line %d]", err.Message, err.Token.Line)
-	fmt.Fprintln(os.Stderr, msg)
+	// Limits can be hit outside of anything with a line
+	if err.Token == nil {
+		fmt.Fprintln(l.Stderr, err.Message)
+		l.HadRuntimeError = true
+		return
+	}
+
+	if len(err.Trace) == 0 {
+		msg := fmt.Sprintf("%s\n[line %d]", err.Message, err.Token.Line)
+		fmt.Fprintln(l.Stderr, msg)
+		l.HadRuntimeError = true
+		return
+	}
+
+	// Each frame's line is where the next frame was called from, the
+	// innermost frame's is where the error happened
+	fmt.Fprintln(l.Stderr, err.Message)
+	line := err.Token.Line
+	for idx := len(err.Trace) - 1; idx >= 0; idx-- {
+		// Deep traces, usually from runaway recursion, only show
+		// their ends
+		if idx == len(err.Trace)-1-traceEdgeFrames && idx >= traceEdgeFrames {
+			skipped := idx - traceEdgeFrames + 1
+			fmt.Fprintf(l.Stderr, "... %d more frames\n", skipped)
+			line = err.Trace[traceEdgeFrames].Line
+			idx = traceEdgeFrames - 1
+		}
+
+		frame := err.Trace[idx]
+		fmt.Fprintf(l.Stderr, "[line %d] in %s\n", line, frame.Name)
+		line = frame.Line
+	}
+	// Calls from Go and the event loop have no line to come from
+	if line > 0 {
+		fmt.Fprintf(l.Stderr, "[line %d] in script\n", line)
+	}
 	l.HadRuntimeError = true
 }
 
-func (l *Lox) resetErrorState() {
+// ResetErrorState clears HadError and HadRuntimeError between
+// scripts.
+func (l *Lox) ResetErrorState() {
 	l.HadError = false
 	l.HadRuntimeError = false
 }
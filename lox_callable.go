@@ -1,6 +1,31 @@
-package main
+package glox
+
+import "fmt"
 
 type LoxCallable interface {
 	Call(*Interpreter, []interface{}) (interface{}, *RuntimeError)
-	Arity() int
+	Arity() Arity
+}
+
+// Arity is the range of argument counts a callable accepts. Min and
+// Max differ when some parameters have default values, and Max is
+// ignored for variadic callables.
+type Arity struct {
+	Min      int
+	Max      int
+	Variadic bool
+}
+
+func (a Arity) accepts(count int) bool {
+	return count >= a.Min && (a.Variadic || count <= a.Max)
+}
+
+func (a Arity) String() string {
+	if a.Variadic {
+		return fmt.Sprintf("at least %d", a.Min)
+	}
+	if a.Min == a.Max {
+		return fmt.Sprintf("%d", a.Min)
+	}
+	return fmt.Sprintf("%d to %d", a.Min, a.Max)
 }
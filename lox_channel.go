@@ -0,0 +1,198 @@
+package glox
+
+import (
+	"fmt"
+	"sync"
+)
+
+// LoxChannel passes values between tasks, from Channel(). Without a
+// capacity, send() waits for a recv().
+type LoxChannel struct {
+	ch     chan interface{}
+	mu     sync.Mutex
+	closed bool
+}
+
+func (c *LoxChannel) String() string {
+	return fmt.Sprintf("<channel %d/%d>", len(c.ch), cap(c.ch))
+}
+
+func (c *LoxChannel) get(name *Token) (interface{}, *RuntimeError) {
+	switch name.Lexeme {
+	case "send":
+		return &NativeFunction{
+			Name:      "send",
+			Signature: Arity{Min: 1, Max: 1},
+			Fn: func(i *Interpreter, args []interface{}) (interface{}, *RuntimeError) {
+				return nil, c.send(i, args[0])
+			},
+		}, nil
+	case "recv":
+		return &NativeFunction{
+			Name:      "recv",
+			Signature: Arity{Min: 0, Max: 0},
+			Fn: func(i *Interpreter, args []interface{}) (interface{}, *RuntimeError) {
+				value, _, err := c.recv(i)
+				return value, err
+			},
+		}, nil
+	case "close":
+		return &NativeFunction{
+			Name:      "close",
+			Signature: Arity{Min: 0, Max: 0},
+			Fn: func(i *Interpreter, args []interface{}) (interface{}, *RuntimeError) {
+				c.mu.Lock()
+				defer c.mu.Unlock()
+				if c.closed {
+					return nil, &RuntimeError{Message: "Channel is already closed."}
+				}
+				c.closed = true
+				close(c.ch)
+				return nil, nil
+			},
+		}, nil
+	}
+
+	return nil, &RuntimeError{
+		Token:   name,
+		Message: fmt.Sprintf("Undefined property '%s'.", name.Lexeme),
+	}
+}
+
+func (c *LoxChannel) set(name *Token, value interface{}) *RuntimeError {
+	return readOnlyError(name, c)
+}
+
+func (c *LoxChannel) send(i *Interpreter, value interface{}) (err *RuntimeError) {
+	// The channel can be closed while send() waits, which panics
+	defer func() {
+		if recover() != nil {
+			err = closedChannelError()
+		}
+	}()
+
+	select {
+	case c.ch <- value:
+		return nil
+	case <-i.done():
+		return i.checkContext()
+	}
+}
+
+// recv returns nil, reporting false, once the channel is closed and
+// empty.
+func (c *LoxChannel) recv(i *Interpreter) (interface{}, bool, *RuntimeError) {
+	select {
+	case value, ok := <-c.ch:
+		return value, ok, nil
+	case <-i.done():
+		return nil, false, i.checkContext()
+	}
+}
+
+func closedChannelError() *RuntimeError {
+	return &RuntimeError{Message: "Cannot send on a closed channel."}
+}
+
+// Channel()
+func channelNative(i *Interpreter, args []interface{}) (interface{}, *RuntimeError) {
+	capacity := 0
+	if len(args) > 0 {
+		var err *RuntimeError
+		capacity, err = intArg("Channel", args, 0)
+		if err != nil {
+			return nil, err
+		}
+		if capacity < 0 {
+			return nil, argumentError("Channel", 0, "a capacity of at least 0")
+		}
+	}
+	return &LoxChannel{ch: make(chan interface{}, capacity)}, nil
+}
+
+// LoxWaitGroup waits for a number of tasks to call done(), from
+// WaitGroup().
+type LoxWaitGroup struct {
+	wg sync.WaitGroup
+	// Kept alongside wg, which panics below zero and is left broken
+	mu    sync.Mutex
+	count int
+}
+
+func (w *LoxWaitGroup) String() string {
+	return "<wait group>"
+}
+
+func (w *LoxWaitGroup) get(name *Token) (interface{}, *RuntimeError) {
+	switch name.Lexeme {
+	case "add":
+		return &NativeFunction{
+			Name:      "add",
+			Signature: Arity{Min: 0, Max: 1},
+			Fn: func(i *Interpreter, args []interface{}) (interface{}, *RuntimeError) {
+				delta := 1
+				if len(args) > 0 {
+					var err *RuntimeError
+					delta, err = intArg("add", args, 0)
+					if err != nil {
+						return nil, err
+					}
+				}
+				return nil, w.add(delta)
+			},
+		}, nil
+	case "done":
+		return &NativeFunction{
+			Name:      "done",
+			Signature: Arity{Min: 0, Max: 0},
+			Fn: func(i *Interpreter, args []interface{}) (interface{}, *RuntimeError) {
+				return nil, w.add(-1)
+			},
+		}, nil
+	case "wait":
+		return &NativeFunction{
+			Name:      "wait",
+			Signature: Arity{Min: 0, Max: 0},
+			Fn: func(i *Interpreter, args []interface{}) (interface{}, *RuntimeError) {
+				// WaitGroup can't be selected on, so wait on the side
+				finished := make(chan struct{})
+				go func() {
+					w.wg.Wait()
+					close(finished)
+				}()
+
+				select {
+				case <-finished:
+					return nil, nil
+				case <-i.done():
+					return nil, i.checkContext()
+				}
+			},
+		}, nil
+	}
+
+	return nil, &RuntimeError{
+		Token:   name,
+		Message: fmt.Sprintf("Undefined property '%s'.", name.Lexeme),
+	}
+}
+
+func (w *LoxWaitGroup) set(name *Token, value interface{}) *RuntimeError {
+	return readOnlyError(name, w)
+}
+
+func (w *LoxWaitGroup) add(delta int) *RuntimeError {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.count+delta < 0 {
+		return &RuntimeError{Message: "Wait group counter cannot go below 0."}
+	}
+	w.count += delta
+	w.wg.Add(delta)
+	return nil
+}
+
+// WaitGroup()
+func waitGroupNative(i *Interpreter, args []interface{}) (interface{}, *RuntimeError) {
+	return &LoxWaitGroup{}, nil
+}
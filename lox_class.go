@@ -1,4 +1,4 @@
-package main
+package glox
 
 type LoxClass struct {
 	Name       string
@@ -11,24 +11,31 @@ func (l *LoxClass) String() string {
 }
 
 func (l *LoxClass) Call(i *Interpreter, args []interface{}) (interface{}, *RuntimeError) {
+	if err := i.allocate(nil); err != nil {
+		return nil, err
+	}
+
 	instance := &LoxInstance{
 		Class:  l,
 		Fields: map[string]interface{}{},
 	}
 
 	if initializer, ok := l.findMethod("init"); ok {
-		initializer.bind(instance).Call(i, args)
+		_, err := initializer.bind(instance).Call(i, args)
+		if err != nil {
+			return nil, err
+		}
 	}
 
 	return instance, nil
 }
 
-func (l *LoxClass) Arity() int {
+func (l *LoxClass) Arity() Arity {
 	if initializer, ok := l.findMethod("init"); ok {
 		return initializer.Arity()
 	}
 
-	return 0
+	return Arity{Min: 0, Max: 0}
 }
 
 func (l *LoxClass) findMethod(name string) (*LoxFunction, bool) {
@@ -42,3 +49,15 @@ func (l *LoxClass) findMethod(name string) (*LoxFunction, bool) {
 
 	return nil, false
 }
+
+func (l *LoxClass) isSubclassOf(class *LoxClass) bool {
+	if l == class {
+		return true
+	}
+
+	if l.Superclass != nil {
+		return l.Superclass.isSubclassOf(class)
+	}
+
+	return false
+}
@@ -1,47 +1,131 @@
-package main
+package glox
 
 import "fmt"
 
+// missingArgument fills the gaps named arguments leave between
+// positional ones, so the callee falls back to the param's default.
+var missingArgument = missing{}
+
+type missing struct{}
+
 type LoxFunction struct {
 	Declaration   *FunctionStmt
 	Closure       *Environment
 	IsInitializer bool
 }
 
+// TailCall is a call returned from a function, for its caller to make
+// instead, so the Go stack doesn't grow.
+type TailCall struct {
+	Function *LoxFunction
+	Args     []interface{}
+	// Where the call was made, for its frame
+	Line int
+}
+
 func (f *LoxFunction) Call(i *Interpreter, args []interface{}) (interface{}, *RuntimeError) {
+	function := f
+	// Tail calls share a frame on top of whatever this was called
+	// from, since not every caller pushes a frame for this call
+	depth := len(i.Frames)
+	for {
+		result, tailCall, err := function.call(i, args)
+		if err != nil || tailCall == nil {
+			if len(i.Frames) > depth {
+				if err != nil && err.Trace == nil {
+					err.Trace = append([]*CallFrame{}, i.Frames...)
+				}
+				i.Frames = i.Frames[:depth]
+			}
+			return result, err
+		}
+
+		function, args = tailCall.Function, tailCall.Args
+		frame := &CallFrame{Name: frameName(function), Line: tailCall.Line}
+		i.Frames = append(i.Frames[:depth], frame)
+	}
+}
+
+// call runs the function body once, returning the tail call it ends
+// with if it has one.
+func (f *LoxFunction) call(i *Interpreter, args []interface{}) (interface{}, *TailCall, *RuntimeError) {
 	// Setup scope
 	environment := NewEnvironment(f.Closure)
-	for i, param := range f.Declaration.Params {
-		environment.define(param.Lexeme, args[i])
+	for idx, param := range f.Declaration.Params {
+		if idx < len(args) && args[idx] != missingArgument {
+			environment.define(param.Lexeme, args[idx])
+			continue
+		}
+
+		// Defaults are evaluated in the call's scope so they can
+		// refer to earlier params
+		value, err := i.evaluateIn(f.Declaration.Defaults[idx], environment)
+		if err != nil {
+			return nil, nil, err
+		}
+		environment.define(param.Lexeme, value)
+	}
+	if f.Declaration.Rest != nil {
+		rest := []interface{}{}
+		if len(args) > len(f.Declaration.Params) {
+			rest = append(rest, args[len(f.Declaration.Params):]...)
+		}
+		environment.define(f.Declaration.Rest.Lexeme, &LoxList{Elements: rest})
+	}
+
+	// Generators run their body when they're resumed
+	if f.Declaration.IsGenerator {
+		return newGenerator(i, f, environment), nil, nil
+	}
+	if f.Declaration.IsAsync {
+		return i.async(f, environment), nil, nil
 	}
 
 	// Execute body
 	err := i.executeBlock(f.Declaration.Body, environment)
 	if err != nil {
 		// Short circuit return statements using errors :P
-		if err.Return != nil {
+		if err.IsReturn {
+			if err.TailCall != nil {
+				return nil, err.TailCall, nil
+			}
+
 			// Return instance instead of nil from `return;` in
 			// init methods
 			if f.IsInitializer {
-				f.Closure.getAt(0, "this")
+				this, err := f.Closure.getAt(0, "this")
+				return this, nil, err
 			}
 
-			return err.Return, nil
+			return err.Return, nil, nil
 		} else {
-			return nil, err
+			return nil, nil, err
 		}
 	}
 
 	// Return instance of class implicetly from init methods
 	if f.IsInitializer {
-		return f.Closure.getAt(0, "this")
+		this, err := f.Closure.getAt(0, "this")
+		return this, nil, err
 	}
 
-	return nil, nil
+	return nil, nil, nil
 }
 
-func (f *LoxFunction) Arity() int {
-	return len(f.Declaration.Params)
+func (f *LoxFunction) Arity() Arity {
+	required := 0
+	for _, def := range f.Declaration.Defaults {
+		if def != nil {
+			break
+		}
+		required++
+	}
+
+	return Arity{
+		Min:      required,
+		Max:      len(f.Declaration.Params),
+		Variadic: f.Declaration.Rest != nil,
+	}
 }
 
 func (f *LoxFunction) String() string {
@@ -0,0 +1,213 @@
+package glox
+
+import (
+	"fmt"
+	"runtime"
+)
+
+// LoxGenerator is what calling a fun* returns. Its body runs on its own
+// goroutine and interpreter, taking turns with whoever calls next(), so
+// it can stop at a yield and carry on from there later.
+type LoxGenerator struct {
+	Function *LoxFunction
+	// Runs the body, with the call's params as its environment
+	interpreter *Interpreter
+	coroutine   *coroutine
+	started     bool
+	finished    bool
+	// A value done() resumed the body for, waiting for next()
+	peeked *generatorResult
+}
+
+// coroutine is the generator's side of the handoff.
+type coroutine struct {
+	resume  chan struct{}
+	results chan generatorResult
+	// Set once the generator is dropped, so nothing waits for its
+	// body to unwind
+	abandoned bool
+}
+
+type generatorResult struct {
+	value interface{}
+	done  bool
+	err   *RuntimeError
+}
+
+func newGenerator(i *Interpreter, function *LoxFunction, environment *Environment) *LoxGenerator {
+	fork := i.fork()
+	fork.Environment = environment
+	fork.coroutine = &coroutine{
+		resume:  make(chan struct{}),
+		results: make(chan generatorResult),
+	}
+
+	generator := &LoxGenerator{
+		Function:    function,
+		interpreter: fork,
+		coroutine:   fork.coroutine,
+	}
+
+	// A generator that's dropped part way through leaves its goroutine
+	// waiting at a yield
+	runtime.SetFinalizer(generator, (*LoxGenerator).abandon)
+
+	return generator
+}
+
+func (g *LoxGenerator) String() string {
+	return fmt.Sprintf("<generator %s>", g.Function.Declaration.Name.Lexeme)
+}
+
+func (g *LoxGenerator) get(name *Token) (interface{}, *RuntimeError) {
+	switch name.Lexeme {
+	case "next":
+		return &NativeFunction{
+			Name:      "next",
+			Signature: Arity{Min: 0, Max: 0},
+			Fn: func(i *Interpreter, args []interface{}) (interface{}, *RuntimeError) {
+				value, _, err := g.next(i)
+				return value, err
+			},
+		}, nil
+	case "done":
+		return &NativeFunction{
+			Name:      "done",
+			Signature: Arity{Min: 0, Max: 0},
+			Fn: func(i *Interpreter, args []interface{}) (interface{}, *RuntimeError) {
+				return g.done(i)
+			},
+		}, nil
+	case "close":
+		return &NativeFunction{
+			Name:      "close",
+			Signature: Arity{Min: 0, Max: 0},
+			Fn: func(i *Interpreter, args []interface{}) (interface{}, *RuntimeError) {
+				g.close()
+				return nil, nil
+			},
+		}, nil
+	}
+
+	return nil, &RuntimeError{
+		Token:   name,
+		Message: fmt.Sprintf("Undefined property '%s'.", name.Lexeme),
+	}
+}
+
+func (g *LoxGenerator) set(name *Token, value interface{}) *RuntimeError {
+	return readOnlyError(name, g)
+}
+
+// next runs the body up to its next yield, reporting false once it's
+// finished. Finished generators return nil.
+func (g *LoxGenerator) next(i *Interpreter) (interface{}, bool, *RuntimeError) {
+	result := g.peeked
+	if result == nil {
+		result = g.resume(i)
+	}
+	g.peeked = nil
+	// Only async bodies finish with a value, generators can't return
+	// one
+	if result.done {
+		return nil, false, result.err
+	}
+	return result.value, true, result.err
+}
+
+// done looks ahead for another value, so scripts can loop until it's
+// true.
+func (g *LoxGenerator) done(i *Interpreter) (interface{}, *RuntimeError) {
+	if g.peeked == nil {
+		g.peeked = g.resume(i)
+	}
+	if g.peeked.err != nil {
+		err := g.peeked.err
+		g.peeked = nil
+		return nil, err
+	}
+	return g.peeked.done, nil
+}
+
+// close stops the body where it's waiting at a yield, so its goroutine
+// exits. The generator is finished afterwards.
+func (g *LoxGenerator) close() {
+	if g.started && !g.finished {
+		close(g.coroutine.resume)
+		// Wait for the body to unwind
+		<-g.coroutine.results
+	}
+	g.finished = true
+	g.peeked = nil
+}
+
+// abandon is close for a dropped generator. It runs on the finalizer
+// goroutine, so it doesn't wait for the body to unwind.
+func (g *LoxGenerator) abandon() {
+	if g.started && !g.finished {
+		g.coroutine.abandoned = true
+		close(g.coroutine.resume)
+	}
+}
+
+func (g *LoxGenerator) resume(i *Interpreter) *generatorResult {
+	if g.finished {
+		return &generatorResult{done: true}
+	}
+
+	// The body runs in place of the call resuming it, so it shares
+	// the caller's budget and stack
+	fork := g.interpreter
+	fork.budget = i.budget
+	fork.Frames = append([]*CallFrame{}, i.Frames...)
+	if top := len(fork.Frames) - 1; top >= 0 {
+		fork.Frames[top] = &CallFrame{
+			Name: frameName(g.Function),
+			Line: fork.Frames[top].Line,
+		}
+	}
+
+	if !g.started {
+		g.started = true
+		go runGenerator(fork, g.Function.Declaration.Body, fork.Environment)
+	} else {
+		g.coroutine.resume <- struct{}{}
+	}
+
+	result := <-g.coroutine.results
+	if result.done || result.err != nil {
+		g.finished = true
+	}
+	if result.err != nil && result.err.Trace == nil {
+		result.err.Trace = fork.Frames
+	}
+	return &result
+}
+
+// runGenerator runs a generator body to the end, finishing with what
+// it returns for async bodies to resolve their promise with. It mustn't
+// refer to the generator itself, or the generator could never be
+// collected.
+func runGenerator(i *Interpreter, body []Stmt, environment *Environment) {
+	err := i.executeBlock(body, environment)
+	if i.coroutine.abandoned {
+		return
+	}
+	var value interface{}
+	if err != nil && err.IsReturn {
+		value = err.Return
+		err = nil
+	}
+	i.coroutine.results <- generatorResult{value: value, done: true, err: err}
+}
+
+// yield hands value to whoever resumed the generator, and waits to be
+// resumed again.
+func (c *coroutine) yield(value interface{}) *RuntimeError {
+	c.results <- generatorResult{value: value}
+	if _, ok := <-c.resume; !ok {
+		// Closed, so unwind the body and let its goroutine exit
+		return &RuntimeError{Message: "Generator closed."}
+	}
+	return nil
+}
@@ -0,0 +1,96 @@
+package glox_test
+
+import (
+	"runtime"
+	"testing"
+	"time"
+)
+
+func TestGeneratorMethodsIterate(t *testing.T) {
+	lox, _ := newLox()
+	run(t, lox, `
+class Range {
+  init(from, to) {
+    this.from = from;
+    this.to = to;
+  }
+  *iterator() {
+    var n = this.from;
+    while (n < this.to) {
+      yield n;
+      n = n + 1;
+    }
+  }
+}
+
+fun sum(from, to) {
+  var total = 0;
+  for (var n in Range(from, to)) total = total + n;
+  return total;
+}
+`)
+
+	if got := call(t, lox, "sum", 1, 5); got != 10.0 {
+		t.Errorf("sum(1, 5) = %v, want 10", got)
+	}
+}
+
+func TestGeneratorsLeftEarlyStop(t *testing.T) {
+	lox, _ := newLox()
+	before := runtime.NumGoroutine()
+	run(t, lox, `
+fun* forever() {
+  var n = 0;
+  while (true) {
+    yield n;
+    n = n + 1;
+  }
+}
+
+// Both still reachable, so only closing them stops their bodies
+var broken = {};
+var closed = {};
+for (var k in range(50)) {
+  broken[k] = forever();
+  for (var n in broken[k]) if (n == 2) break;
+  closed[k] = forever();
+  closed[k].next();
+  closed[k].close();
+}
+`)
+
+	// Bodies finish unwinding just after close returns
+	expectGoroutines(t, before)
+}
+
+func TestDroppedGeneratorsStop(t *testing.T) {
+	lox, _ := newLox()
+	before := runtime.NumGoroutine()
+	run(t, lox, `
+fun* forever() {
+  while (true) yield 1;
+}
+fun startOne() {
+  var numbers = forever();
+  numbers.next();
+}
+for (var k in range(50)) startOne();
+`)
+
+	// Finalizers stop them, without waiting on each other
+	expectGoroutines(t, before)
+}
+
+// expectGoroutines waits for the goroutines running to drop back to
+// want, collecting garbage so finalizers run.
+func expectGoroutines(t *testing.T, want int) {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for runtime.NumGoroutine() > want && time.Now().Before(deadline) {
+		runtime.GC()
+		time.Sleep(time.Millisecond)
+	}
+	if after := runtime.NumGoroutine(); after > want {
+		t.Errorf("%d goroutines left running, want none", after-want)
+	}
+}
@@ -0,0 +1,129 @@
+package glox
+
+import (
+	"fmt"
+	"reflect"
+	"unicode"
+	"unicode/utf8"
+)
+
+// GoObject exposes a Go struct, or pointer to one, to scripts. Its
+// exported fields and methods are its properties, and scripts can use
+// the Go name or the same name starting in lowercase. Go values with
+// no Lox equivalent, like funcs and channels, are GoObjects too, with
+// only their methods.
+type GoObject struct {
+	Value reflect.Value
+}
+
+func (o *GoObject) String() string {
+	if stringer, ok := o.Value.Interface().(fmt.Stringer); ok {
+		return stringer.String()
+	}
+	return fmt.Sprintf("<go %s>", o.Value.Type())
+}
+
+func (o *GoObject) get(name *Token) (interface{}, *RuntimeError) {
+	// Fields shadow methods, like they do on instances
+	field, ok, err := o.field(name)
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		return fromGoValue(field), nil
+	}
+
+	for _, goName := range goNames(name.Lexeme) {
+		method := o.Value.MethodByName(goName)
+		if !method.IsValid() {
+			continue
+		}
+
+		native, err := bindGoFunc(name.Lexeme, method)
+		if err != nil {
+			return nil, &RuntimeError{Token: name, Message: err.Error()}
+		}
+		return native, nil
+	}
+
+	return nil, &RuntimeError{
+		Token:   name,
+		Message: fmt.Sprintf("Undefined property '%s'.", name.Lexeme),
+	}
+}
+
+func (o *GoObject) set(name *Token, value interface{}) *RuntimeError {
+	field, ok, err := o.field(name)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return &RuntimeError{
+			Token:   name,
+			Message: fmt.Sprintf("Undefined property '%s'.", name.Lexeme),
+		}
+	}
+
+	if !field.CanSet() {
+		return &RuntimeError{
+			Token:   name,
+			Message: fmt.Sprintf("Cannot set property '%s' on a Go struct passed by value.", name.Lexeme),
+		}
+	}
+
+	goValue, ok := toGoValue(value, field.Type())
+	if !ok {
+		return &RuntimeError{
+			Token:   name,
+			Message: fmt.Sprintf("Property '%s' must be %s.", name.Lexeme, goTypeDescription(field.Type())),
+		}
+	}
+
+	field.Set(goValue)
+	return nil
+}
+
+func (o *GoObject) field(name *Token) (reflect.Value, bool, *RuntimeError) {
+	structValue := o.Value
+	if structValue.Kind() == reflect.Ptr {
+		structValue = structValue.Elem()
+	}
+	if structValue.Kind() != reflect.Struct {
+		return reflect.Value{}, false, nil
+	}
+
+	for _, goName := range goNames(name.Lexeme) {
+		structField, ok := structValue.Type().FieldByName(goName)
+		if !ok || structField.PkgPath != "" {
+			continue
+		}
+
+		// Promoted fields can be behind a nil embedded pointer
+		field, err := structValue.FieldByIndexErr(structField.Index)
+		if err != nil {
+			return reflect.Value{}, false, &RuntimeError{
+				Token:   name,
+				Message: fmt.Sprintf("Cannot reach property '%s' through a nil embedded struct.", name.Lexeme),
+			}
+		}
+		return field, true, nil
+	}
+
+	return reflect.Value{}, false, nil
+}
+
+// goNames lists the Go identifiers a Lox property name could refer to.
+func goNames(name string) []string {
+	first, size := utf8.DecodeRuneInString(name)
+	if unicode.IsUpper(first) {
+		return []string{name}
+	}
+	return []string{name, string(unicode.ToUpper(first)) + name[size:]}
+}
+
+// SetGlobal makes a Go value available to scripts under name, after
+// converting it to Lox. Structs, pointers to structs and values Lox
+// has no equivalent for are wrapped in a GoObject.
+func (i *Interpreter) SetGlobal(name string, value interface{}) {
+	i.Globals.define(name, fromGoValue(reflect.ValueOf(value)))
+}
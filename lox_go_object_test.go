@@ -0,0 +1,86 @@
+package glox_test
+
+import "testing"
+
+type account struct {
+	Owner   string
+	Balance float64
+	secret  string
+}
+
+func (a *account) Deposit(amount float64) float64 {
+	a.Balance += amount
+	return a.Balance
+}
+
+func TestSetGlobalConvertsValues(t *testing.T) {
+	lox, _ := newLox()
+	lox.Interpreter.SetGlobal("limit", 10)
+	lox.Interpreter.SetGlobal("names", []string{"a", "b"})
+	lox.Interpreter.SetGlobal("nothing", nil)
+	run(t, lox, `fun check() { return limit == 10 and names[1] == "b" and nothing == nil; }`)
+
+	if got := call(t, lox, "check"); got != true {
+		t.Errorf("check() = %v, want true", got)
+	}
+}
+
+func TestGoObjectFieldsAndMethods(t *testing.T) {
+	lox, _ := newLox()
+	acct := &account{Owner: "ann", Balance: 5}
+	lox.Interpreter.SetGlobal("acct", acct)
+	run(t, lox, `
+acct.balance = acct.balance + 1;
+acct.Owner = "bob";
+fun deposited() { return acct.deposit(4); }
+`)
+
+	if got := call(t, lox, "deposited"); got != 10.0 {
+		t.Errorf("deposited() = %v, want 10", got)
+	}
+	if acct.Owner != "bob" || acct.Balance != 10 {
+		t.Errorf("account is %+v", acct)
+	}
+
+	runError(t, lox, `acct.balance = "lots";`, "Property 'balance' must be a number.")
+	runError(t, lox, `acct.secret;`, "Undefined property 'secret'.")
+}
+
+func TestGoObjectByValueIsReadOnly(t *testing.T) {
+	lox, _ := newLox()
+	lox.Interpreter.SetGlobal("acct", account{Owner: "ann"})
+	run(t, lox, `fun owner() { return acct.owner; }`)
+
+	if got := call(t, lox, "owner"); got != "ann" {
+		t.Errorf("owner() = %v, want ann", got)
+	}
+	runError(t, lox, `acct.owner = "bob";`, "Cannot set property 'owner' on a Go struct passed by value.")
+}
+
+func TestGoObjectsComeBackUnwrapped(t *testing.T) {
+	lox, _ := newLox()
+	acct := &account{Owner: "ann"}
+	lox.Interpreter.SetGlobal("acct", acct)
+	run(t, lox, `fun same() { return acct; }`)
+
+	if got := call(t, lox, "same"); got != acct {
+		t.Errorf("same() = %v, want the account passed in", got)
+	}
+}
+
+type savings struct {
+	*account
+	Rate float64
+}
+
+func TestGoObjectNilEmbeddedStruct(t *testing.T) {
+	lox, _ := newLox()
+	lox.Interpreter.SetGlobal("plan", &savings{Rate: 2})
+	run(t, lox, `fun rate() { return plan.rate; }`)
+
+	if got := call(t, lox, "rate"); got != 2.0 {
+		t.Errorf("rate() = %v, want 2", got)
+	}
+	runError(t, lox, `plan.owner;`, "Cannot reach property 'owner' through a nil embedded struct.")
+	runError(t, lox, `plan.owner = "ann";`, "Cannot reach property 'owner' through a nil embedded struct.")
+}
@@ -0,0 +1,137 @@
+package glox
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+)
+
+var handleType = reflect.TypeOf((*Handle)(nil))
+
+// Handle lets Go call a Lox function or class after the script that
+// defined it has run. Arguments are converted from Go like Bind's
+// results are, and results come back as Values, with lists and maps
+// copied into Go ones and callables as handles of their own.
+type Handle struct {
+	Interpreter *Interpreter
+	Callable    LoxCallable
+}
+
+func (h *Handle) String() string {
+	return fmt.Sprint(h.Callable)
+}
+
+// Call runs the callable, returning its runtime error if it has one.
+func (h *Handle) Call(args ...interface{}) (Value, error) {
+	return h.CallContext(context.Background(), args...)
+}
+
+// CallContext is Call with the interpreter's Limits applied under ctx,
+// like Interpret. Calls to async functions return once their promise is
+// settled.
+func (h *Handle) CallContext(ctx context.Context, args ...interface{}) (Value, error) {
+	loxArgs := []interface{}{}
+	for _, arg := range args {
+		loxArgs = append(loxArgs, fromGoValue(reflect.ValueOf(arg)))
+	}
+
+	arity := h.Callable.Arity()
+	if !arity.accepts(len(loxArgs)) {
+		return nil, fmt.Errorf("Expected %s arguments but got %d.", arity, len(loxArgs))
+	}
+
+	// There's no call site, so the frame has no line
+	i := h.Interpreter
+	var result interface{}
+	err := i.limited(ctx, func() *RuntimeError {
+		var err *RuntimeError
+		result, err = i.call(h.Callable, loxArgs, nil)
+		if err != nil {
+			return err
+		}
+
+		// Async functions are waited for, leaving anything else on the
+		// event loop for later
+		if promise, ok := result.(*LoxPromise); ok {
+			if err = i.runLoop(promise); err != nil {
+				return err
+			}
+			result, err = promise.result()
+		}
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return exportValue(i, result), nil
+}
+
+// Callable returns a handle for the global function or class name.
+func (i *Interpreter) Callable(name string) (*Handle, error) {
+	value, ok := i.Globals.lookup(name)
+	if !ok {
+		return nil, fmt.Errorf("Undefined variable '%s'.", name)
+	}
+
+	callable, ok := value.(LoxCallable)
+	if !ok {
+		return nil, fmt.Errorf("'%s' is not a function or class.", name)
+	}
+	return &Handle{Interpreter: i, Callable: callable}, nil
+}
+
+// Call calls the global function or class name, for running a
+// script's handlers after the script itself has run.
+func (i *Interpreter) Call(name string, args ...interface{}) (Value, error) {
+	handle, err := i.Callable(name)
+	if err != nil {
+		return nil, err
+	}
+	return handle.Call(args...)
+}
+
+// exportValue prepares a Lox value for Go. Lists and maps are copied
+// into []Value and map[string]Value, with keys that aren't strings
+// written like str() writes them. Go values come back unwrapped and
+// callables as handles, the rest are left alone.
+func exportValue(i *Interpreter, val interface{}) Value {
+	return exportNested(i, val, map[interface{}]Value{})
+}
+
+// exportNested exports val, reusing the copies in exported so lists and
+// maps that contain themselves are only copied once.
+func exportNested(i *Interpreter, val interface{}, exported map[interface{}]Value) Value {
+	switch v := val.(type) {
+	case *LoxList:
+		if result, ok := exported[v]; ok {
+			return result
+		}
+		elements := v.elements()
+		result := make([]Value, len(elements))
+		exported[v] = result
+		for idx, element := range elements {
+			result[idx] = exportNested(i, element, exported)
+		}
+		return result
+	case *LoxMap:
+		if result, ok := exported[v]; ok {
+			return result
+		}
+		keys := v.keys()
+		result := make(map[string]Value, len(keys))
+		exported[v] = result
+		for _, key := range keys {
+			name, ok := key.(string)
+			if !ok {
+				name = i.stringify(key)
+			}
+			result[name] = exportNested(i, v.get(key), exported)
+		}
+		return result
+	case *GoObject:
+		return v.Value.Interface()
+	case LoxCallable:
+		return &Handle{Interpreter: i, Callable: v}
+	}
+	return val
+}
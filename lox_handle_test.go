@@ -0,0 +1,134 @@
+package glox_test
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/alexmarchant/glox"
+)
+
+func TestCallConvertsArguments(t *testing.T) {
+	lox, _ := newLox()
+	run(t, lox, `fun describe(n, s, list) { return s + ":" + type(n) + ":" + type(list); }`)
+
+	if got := call(t, lox, "describe", 3, "x", []int{1}); got != "x:number:list" {
+		t.Errorf("describe() = %v", got)
+	}
+}
+
+func TestCallExportsListsAndMaps(t *testing.T) {
+	lox, _ := newLox()
+	run(t, lox, `
+fun profile() {
+  var tags = ["a", "b"];
+  var profile = {"name": "ann", "tags": tags, 1: true};
+  tags[1] = profile;
+  return profile;
+}
+`)
+
+	profile, ok := call(t, lox, "profile").(map[string]glox.Value)
+	if !ok {
+		t.Fatal("profile() didn't return a map[string]Value")
+	}
+	tags, ok := profile["tags"].([]glox.Value)
+	if !ok || len(tags) != 2 || tags[0] != "a" {
+		t.Fatalf("tags = %#v, want a []Value starting with a", profile["tags"])
+	}
+	// The map holds itself through tags, so that's a copy of itself too
+	if nested, ok := tags[1].(map[string]glox.Value); !ok || nested["name"] != "ann" {
+		t.Errorf("tags[1] = %#v, want the profile", tags[1])
+	}
+	// Keys that aren't strings are written like str() writes them
+	if profile["1.000000"] != true {
+		t.Errorf("profile = %#v, want a 1.000000 key", profile)
+	}
+}
+
+func TestCallErrors(t *testing.T) {
+	lox, _ := newLox()
+	run(t, lox, `
+var notFunction = 1;
+fun fail(message) { return nothing; }
+`)
+
+	tests := []struct {
+		name    string
+		args    []interface{}
+		message string
+	}{
+		{"missing", nil, "Undefined variable 'missing'."},
+		{"notFunction", nil, "'notFunction' is not a function or class."},
+		{"fail", nil, "Expected 1 arguments but got 0."},
+		{"fail", []interface{}{"x"}, "Undefined variable 'nothing'."},
+	}
+	for _, test := range tests {
+		_, err := lox.Interpreter.Call(test.name, test.args...)
+		if err == nil || !strings.Contains(err.Error(), test.message) {
+			t.Errorf("Call(%q) error = %v, want %q", test.name, err, test.message)
+		}
+	}
+}
+
+func TestCallableHandles(t *testing.T) {
+	lox, _ := newLox()
+	run(t, lox, `
+class Counter {
+  init(start) { this.count = start; }
+  add() { this.count = this.count + 1; return this.count; }
+}
+fun adder(n) {
+  fun add(x) { return x + n; }
+  return add;
+}
+`)
+
+	counter, err := lox.Interpreter.Callable("Counter")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := counter.Call(1); err != nil {
+		t.Fatal(err)
+	}
+
+	// Returned functions come back as handles
+	add2, ok := call(t, lox, "adder", 2).(*glox.Handle)
+	if !ok {
+		t.Fatal("adder() didn't return a handle")
+	}
+	got, err := add2.Call(40)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != 42.0 {
+		t.Errorf("add2(40) = %v, want 42", got)
+	}
+}
+
+func TestHandlesAsCallbacks(t *testing.T) {
+	lox, _ := newLox()
+	var results []glox.Value
+	err := lox.Interpreter.Bind("each", func(items []float64, fn *glox.Handle) error {
+		for _, item := range items {
+			result, err := fn.Call(item)
+			if err != nil {
+				return err
+			}
+			results = append(results, result)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	run(t, lox, `fun tenTimes(x) { return x * 10; }
+each([1, 2], tenTimes);`)
+	if len(results) != 2 || results[0] != 10.0 || results[1] != 20.0 {
+		t.Errorf("results = %v, want [10 20]", results)
+	}
+
+	// Errors in the callback fail the native that called it
+	runError(t, lox, `fun broken(x) { return x + "a"; }
+each([1], broken);`, "Operands must be two numbers or two strings.")
+}
@@ -1,10 +1,16 @@
-package main
+package glox
 
-import "fmt"
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
 
 type LoxInstance struct {
 	Class  *LoxClass
 	Fields map[string]interface{}
+	// Instances can be shared between tasks
+	mu sync.RWMutex
 }
 
 func (l *LoxInstance) String() string {
@@ -12,7 +18,7 @@ func (l *LoxInstance) String() string {
 }
 
 func (l *LoxInstance) get(name *Token) (interface{}, *RuntimeError) {
-	if val, ok := l.Fields[name.Lexeme]; ok {
+	if val, ok := l.field(name.Lexeme); ok {
 		return val, nil
 	}
 
@@ -26,6 +32,29 @@ func (l *LoxInstance) get(name *Token) (interface{}, *RuntimeError) {
 	}
 }
 
-func (l *LoxInstance) set(name *Token, value interface{}) {
+func (l *LoxInstance) set(name *Token, value interface{}) *RuntimeError {
+	l.mu.Lock()
+	defer l.mu.Unlock()
 	l.Fields[name.Lexeme] = value
+	return nil
+}
+
+func (l *LoxInstance) field(name string) (interface{}, bool) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	val, ok := l.Fields[name]
+	return val, ok
+}
+
+// fieldNames returns the names of the fields in order, since they have
+// no order of their own.
+func (l *LoxInstance) fieldNames() []string {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	names := []string{}
+	for name := range l.Fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
 }
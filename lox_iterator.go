@@ -0,0 +1,113 @@
+package glox
+
+import "fmt"
+
+// iterator returns the next value of a for-in loop, reporting false
+// once there are no more.
+type iterator func() (interface{}, bool, *RuntimeError)
+
+// iterator makes an iterator over val. Instances are iterable through
+// an iterator() method returning an instance with a next() method, which
+// returns nil when it's done. iterator() can return a generator too.
+// The func returned with it, if any, lets go of val once a loop is done
+// with it.
+func (i *Interpreter) iterator(val interface{}, token *Token) (iterator, func(), *RuntimeError) {
+	switch v := val.(type) {
+	case *LoxList:
+		// Elements added during the loop are included
+		idx := 0
+		return func() (interface{}, bool, *RuntimeError) {
+			element, ok := v.at(idx)
+			if !ok {
+				return nil, false, nil
+			}
+			idx++
+			return element, true, nil
+		}, nil, nil
+	case *LoxMap:
+		return sliceIterator(v.keys()), nil, nil
+	case string:
+		chars := []interface{}{}
+		for _, char := range v {
+			chars = append(chars, string(char))
+		}
+		return sliceIterator(chars), nil, nil
+	case *LoxRange:
+		current := v.Start
+		return func() (interface{}, bool, *RuntimeError) {
+			if !v.includes(current) {
+				return nil, false, nil
+			}
+			current += v.Step
+			return current - v.Step, true, nil
+		}, nil, nil
+	case *LoxGenerator:
+		// A loop left part way through closes the generator
+		return func() (interface{}, bool, *RuntimeError) {
+			return v.next(i)
+		}, v.close, nil
+	case *LoxChannel:
+		// Runs until the channel is closed
+		return func() (interface{}, bool, *RuntimeError) {
+			return v.recv(i)
+		}, nil, nil
+	case *LoxInstance:
+		if _, ok := v.Class.findMethod("iterator"); !ok {
+			next, err := i.nextMethodIterator(v, token)
+			return next, nil, err
+		}
+
+		result, err := i.callMethod(v, "iterator", token)
+		if err != nil {
+			return nil, nil, err
+		}
+		if instance, ok := result.(*LoxInstance); ok {
+			next, err := i.nextMethodIterator(instance, token)
+			return next, nil, err
+		}
+		return i.iterator(result, token)
+	}
+
+	return nil, nil, &RuntimeError{
+		Token:   token,
+		Message: fmt.Sprintf("Cannot iterate over %s.", typeName(val)),
+	}
+}
+
+func sliceIterator(values []interface{}) iterator {
+	idx := 0
+	return func() (interface{}, bool, *RuntimeError) {
+		if idx >= len(values) {
+			return nil, false, nil
+		}
+		idx++
+		return values[idx-1], true, nil
+	}
+}
+
+func (i *Interpreter) nextMethodIterator(instance *LoxInstance, token *Token) (iterator, *RuntimeError) {
+	if _, ok := instance.Class.findMethod("next"); !ok {
+		return nil, &RuntimeError{
+			Token:   token,
+			Message: fmt.Sprintf("Cannot iterate over %s, it has no iterator() or next() method.", instance),
+		}
+	}
+
+	return func() (interface{}, bool, *RuntimeError) {
+		value, err := i.callMethod(instance, "next", token)
+		return value, value != nil, err
+	}, nil
+}
+
+// callMethod calls an instance's method with no arguments, on behalf of
+// the statement at token.
+func (i *Interpreter) callMethod(instance *LoxInstance, name string, token *Token) (interface{}, *RuntimeError) {
+	method, _ := instance.Class.findMethod(name)
+	if arity := method.Arity(); !arity.accepts(0) {
+		return nil, &RuntimeError{
+			Token:   token,
+			Message: fmt.Sprintf("Expected %s() to take no arguments but it takes %s.", name, arity),
+		}
+	}
+	return i.call(method.bind(instance), []interface{}{}, token)
+}
@@ -0,0 +1,79 @@
+package glox
+
+import (
+	"fmt"
+	"sync"
+)
+
+type LoxList struct {
+	Elements []interface{}
+	// Lists can be shared between tasks
+	mu sync.RWMutex
+}
+
+func (l *LoxList) get(bracket *Token, index interface{}) (interface{}, *RuntimeError) {
+	idx, err := l.index(bracket, index)
+	if err != nil {
+		return nil, err
+	}
+
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return l.Elements[idx], nil
+}
+
+func (l *LoxList) set(bracket *Token, index interface{}, value interface{}) *RuntimeError {
+	idx, err := l.index(bracket, index)
+	if err != nil {
+		return err
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.Elements[idx] = value
+	return nil
+}
+
+// at returns the element at idx, reporting false if there isn't one.
+func (l *LoxList) at(idx int) (interface{}, bool) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	if idx < 0 || idx >= len(l.Elements) {
+		return nil, false
+	}
+	return l.Elements[idx], true
+}
+
+func (l *LoxList) len() int {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return len(l.Elements)
+}
+
+// elements returns a copy of the elements, for reading while tasks
+// change the list.
+func (l *LoxList) elements() []interface{} {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+	return append([]interface{}{}, l.Elements...)
+}
+
+func (l *LoxList) index(bracket *Token, index interface{}) (int, *RuntimeError) {
+	fltIndex, ok := index.(float64)
+	if !ok || fltIndex != float64(int(fltIndex)) {
+		return 0, &RuntimeError{
+			Token:   bracket,
+			Message: "List index must be an integer.",
+		}
+	}
+
+	idx := int(fltIndex)
+	if idx < 0 || idx >= l.len() {
+		return 0, &RuntimeError{
+			Token:   bracket,
+			Message: fmt.Sprintf("List index %d out of range.", idx),
+		}
+	}
+
+	return idx, nil
+}
@@ -0,0 +1,55 @@
+package glox
+
+import "sync"
+
+// LoxMap keeps keys in insertion order so printing and JSON output
+// are stable.
+type LoxMap struct {
+	Keys    []interface{}
+	Entries map[interface{}]interface{}
+	// Maps can be shared between tasks
+	mu sync.RWMutex
+}
+
+func NewLoxMap() *LoxMap {
+	return &LoxMap{
+		Keys:    []interface{}{},
+		Entries: map[interface{}]interface{}{},
+	}
+}
+
+// get returns nil for missing keys.
+func (m *LoxMap) get(key interface{}) interface{} {
+	value, _ := m.lookup(key)
+	return value
+}
+
+func (m *LoxMap) lookup(key interface{}) (interface{}, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	value, ok := m.Entries[key]
+	return value, ok
+}
+
+func (m *LoxMap) set(key interface{}, value interface{}) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if _, ok := m.Entries[key]; !ok {
+		m.Keys = append(m.Keys, key)
+	}
+	m.Entries[key] = value
+}
+
+func (m *LoxMap) len() int {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return len(m.Keys)
+}
+
+// keys returns a copy of the keys in order, for reading while tasks
+// change the map.
+func (m *LoxMap) keys() []interface{} {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return append([]interface{}{}, m.Keys...)
+}
@@ -0,0 +1,28 @@
+package glox
+
+import "fmt"
+
+// LoxModule groups natives under a name, like json.parse().
+type LoxModule struct {
+	Name    string
+	Members map[string]interface{}
+}
+
+func (m *LoxModule) String() string {
+	return fmt.Sprintf("<module %s>", m.Name)
+}
+
+func (m *LoxModule) get(name *Token) (interface{}, *RuntimeError) {
+	if val, ok := m.Members[name.Lexeme]; ok {
+		return val, nil
+	}
+
+	return nil, &RuntimeError{
+		Token:   name,
+		Message: fmt.Sprintf("Undefined property '%s' in module %s.", name.Lexeme, m.Name),
+	}
+}
+
+func (m *LoxModule) set(name *Token, value interface{}) *RuntimeError {
+	return readOnlyError(name, m)
+}
@@ -0,0 +1,17 @@
+package glox
+
+import "fmt"
+
+// LoxObject is anything with properties reachable through `.`, like
+// instances, native modules and Go values passed in by embedders.
+type LoxObject interface {
+	get(name *Token) (interface{}, *RuntimeError)
+	set(name *Token, value interface{}) *RuntimeError
+}
+
+func readOnlyError(name *Token, object interface{}) *RuntimeError {
+	return &RuntimeError{
+		Token:   name,
+		Message: fmt.Sprintf("Cannot set property '%s' on %s.", name.Lexeme, typeName(object)),
+	}
+}
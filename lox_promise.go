@@ -0,0 +1,369 @@
+package glox
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+)
+
+// LoxPromise is a value that's settled later, from calling an async
+// fun, setTimeout() or NewPromise. Awaiting it gives its value, or
+// fails with its error.
+type LoxPromise struct {
+	loop      *eventLoop
+	mu        sync.Mutex
+	settled   bool
+	value     interface{}
+	err       *RuntimeError
+	callbacks []promiseCallback
+	// Set once something waits for it, so rejections nobody waits for
+	// can be reported
+	handled bool
+	// Set on promises from NewPromise until Go settles them
+	external bool
+}
+
+func (p *LoxPromise) String() string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	switch {
+	case !p.settled:
+		return "<promise pending>"
+	case p.err != nil:
+		return "<promise rejected>"
+	}
+	return "<promise fulfilled>"
+}
+
+func (p *LoxPromise) get(name *Token) (interface{}, *RuntimeError) {
+	switch name.Lexeme {
+	case "then":
+		return &NativeFunction{
+			Name:      "then",
+			Signature: Arity{Min: 1, Max: 1},
+			Fn: func(i *Interpreter, args []interface{}) (interface{}, *RuntimeError) {
+				callback, ok := args[0].(LoxCallable)
+				if !ok || !callback.Arity().accepts(1) {
+					return nil, argumentError("then", 0, "a function taking 1 argument")
+				}
+
+				// Errors skip the callback and carry on down the chain
+				next := &LoxPromise{loop: i.loop}
+				p.then(i.loop, func(i *Interpreter) *RuntimeError {
+					value, err := p.result()
+					if err != nil {
+						next.resolve(nil, err)
+					} else {
+						next.resolve(i.call(callback, []interface{}{value}, nil))
+					}
+					return nil
+				})
+				return next, nil
+			},
+		}, nil
+	}
+
+	return nil, &RuntimeError{
+		Token:   name,
+		Message: fmt.Sprintf("Undefined property '%s'.", name.Lexeme),
+	}
+}
+
+func (p *LoxPromise) set(name *Token, value interface{}) *RuntimeError {
+	return readOnlyError(name, p)
+}
+
+// NewPromise returns a pending promise for Go to settle, so natives can
+// do slow work without blocking the script. Scripts keep running the
+// event loop until it's settled.
+func (i *Interpreter) NewPromise() *LoxPromise {
+	i.loop.expect()
+	return &LoxPromise{loop: i.loop, external: true}
+}
+
+// Resolve fulfils a promise from NewPromise with value, converted like
+// Bind's results. It's safe to call from any goroutine, and only the
+// first Resolve or Reject counts.
+func (p *LoxPromise) Resolve(value interface{}) {
+	p.settleFromGo(fromGoValue(reflect.ValueOf(value)), nil)
+}
+
+// Reject fails a promise from NewPromise with err's message.
+func (p *LoxPromise) Reject(err error) {
+	p.settleFromGo(nil, &RuntimeError{Message: err.Error()})
+}
+
+func (p *LoxPromise) settleFromGo(value interface{}, err *RuntimeError) {
+	p.mu.Lock()
+	external := p.external
+	p.external = false
+	p.mu.Unlock()
+	if !external {
+		return
+	}
+
+	p.loop.deliver(func(i *Interpreter) *RuntimeError {
+		p.resolve(value, err)
+		return nil
+	})
+}
+
+// resolve settles the promise, or settles it like value once value is
+// a promise that's settled.
+func (p *LoxPromise) resolve(value interface{}, err *RuntimeError) {
+	if inner, ok := value.(*LoxPromise); ok && err == nil {
+		inner.then(p.loop, func(i *Interpreter) *RuntimeError {
+			p.resolve(inner.result())
+			return nil
+		})
+		return
+	}
+
+	p.mu.Lock()
+	if p.settled {
+		p.mu.Unlock()
+		return
+	}
+	p.settled = true
+	p.value = value
+	p.err = err
+	callbacks := p.callbacks
+	p.callbacks = nil
+	handled := p.handled
+	p.mu.Unlock()
+
+	for _, callback := range callbacks {
+		if callback.loop == p.loop {
+			callback.loop.post(callback.task)
+		} else {
+			callback.loop.deliver(callback.task)
+		}
+	}
+	if err != nil && !handled {
+		p.loop.reject(p)
+	}
+}
+
+// promiseCallback is a task waiting for a promise, and the event loop
+// it runs on.
+type promiseCallback struct {
+	loop *eventLoop
+	task loopTask
+}
+
+// then runs task on loop once the promise is settled. Tasks have loops
+// of their own, and loop keeps running for promises settled on another
+// one.
+func (p *LoxPromise) then(loop *eventLoop, task loopTask) {
+	p.mu.Lock()
+	p.handled = true
+	if !p.settled {
+		if loop != p.loop {
+			loop.expect()
+		}
+		p.callbacks = append(p.callbacks, promiseCallback{loop: loop, task: task})
+		p.mu.Unlock()
+		return
+	}
+	p.mu.Unlock()
+	loop.post(task)
+}
+
+func (p *LoxPromise) isSettled() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.settled
+}
+
+// result returns a settled promise's value. Errors are copies, since
+// there can be more than one waiter.
+func (p *LoxPromise) result() (interface{}, *RuntimeError) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.handled = true
+	if p.err != nil {
+		err := *p.err
+		return nil, &err
+	}
+	return p.value, nil
+}
+
+// async starts an async function's body, which runs until its first
+// await before the call returns. Bodies run like generators that yield
+// the promises they await.
+func (i *Interpreter) async(function *LoxFunction, environment *Environment) *LoxPromise {
+	promise := &LoxPromise{loop: i.loop}
+	i.resumeAsync(newGenerator(i, function, environment), promise)
+	return promise
+}
+
+func (i *Interpreter) resumeAsync(body *LoxGenerator, promise *LoxPromise) {
+	result := body.resume(i)
+	if result.done || result.err != nil {
+		promise.resolve(result.value, result.err)
+		return
+	}
+
+	awaited := result.value.(*LoxPromise)
+	awaited.then(promise.loop, func(i *Interpreter) *RuntimeError {
+		i.resumeAsync(body, promise)
+		return nil
+	})
+}
+
+// await hands promise to whoever resumed the async body, and waits to
+// be resumed once it's settled.
+func (c *coroutine) await(promise *LoxPromise) (interface{}, *RuntimeError) {
+	if err := c.yield(promise); err != nil {
+		return nil, err
+	}
+	return promise.result()
+}
+
+// loopTask is work for the event loop, run by the interpreter that owns
+// it.
+type loopTask func(i *Interpreter) *RuntimeError
+
+// eventLoop runs async bodies, timers and promise callbacks one at a
+// time, in the order they're ready.
+type eventLoop struct {
+	mu    sync.Mutex
+	queue []loopTask
+	// Timers and promises from NewPromise that will deliver a task
+	// later
+	pending int
+	wake    chan struct{}
+	// Rejected promises that weren't being waited for
+	rejected []*LoxPromise
+}
+
+func newEventLoop() *eventLoop {
+	return &eventLoop{wake: make(chan struct{}, 1)}
+}
+
+// post queues task to run. It's safe to call from any goroutine.
+func (l *eventLoop) post(task loopTask) {
+	l.mu.Lock()
+	l.queue = append(l.queue, task)
+	l.mu.Unlock()
+	l.signal()
+}
+
+// expect keeps the loop running until a task is delivered.
+func (l *eventLoop) expect() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.pending++
+}
+
+// deliver posts a task the loop expected.
+func (l *eventLoop) deliver(task loopTask) {
+	l.mu.Lock()
+	l.pending--
+	l.queue = append(l.queue, task)
+	l.mu.Unlock()
+	l.signal()
+}
+
+func (l *eventLoop) signal() {
+	select {
+	case l.wake <- struct{}{}:
+	default:
+	}
+}
+
+func (l *eventLoop) reject(promise *LoxPromise) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.rejected = append(l.rejected, promise)
+}
+
+// next takes the next task off the queue, reporting how many are still
+// expected.
+func (l *eventLoop) next() (loopTask, int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if len(l.queue) == 0 {
+		return nil, l.pending
+	}
+	task := l.queue[0]
+	l.queue = l.queue[1:]
+	return task, l.pending
+}
+
+// unhandled returns the error of a rejected promise nothing waited for,
+// which would otherwise be lost.
+func (l *eventLoop) unhandled() *RuntimeError {
+	l.mu.Lock()
+	rejected := l.rejected
+	l.rejected = nil
+	l.mu.Unlock()
+
+	for _, promise := range rejected {
+		promise.mu.Lock()
+		handled := promise.handled
+		promise.mu.Unlock()
+		if !handled {
+			_, err := promise.result()
+			return err
+		}
+	}
+	return nil
+}
+
+// runLoop runs the event loop until promise is settled, or until
+// there's nothing left to do when promise is nil.
+func (i *Interpreter) runLoop(promise *LoxPromise) *RuntimeError {
+	if promise != nil && promise.loop != i.loop {
+		// Wake up once it's settled on its own loop
+		promise.then(i.loop, func(i *Interpreter) *RuntimeError { return nil })
+	}
+
+	for promise == nil || !promise.isSettled() {
+		task, pending := i.loop.next()
+		if task != nil {
+			if err := task(i); err != nil {
+				return err
+			}
+			continue
+		}
+
+		if pending == 0 {
+			if promise != nil {
+				return &RuntimeError{Message: "Awaiting a promise that can never be settled."}
+			}
+			return i.loop.unhandled()
+		}
+
+		select {
+		case <-i.loop.wake:
+		case <-i.done():
+			return i.checkContext()
+		}
+	}
+	return nil
+}
+
+// setTimeout()
+func setTimeoutNative(i *Interpreter, args []interface{}) (interface{}, *RuntimeError) {
+	callback, ok := args[0].(LoxCallable)
+	if !ok || !callback.Arity().accepts(0) {
+		return nil, argumentError("setTimeout", 0, "a function taking no arguments")
+	}
+	ms, ok := args[1].(float64)
+	if !ok || ms < 0 {
+		return nil, argumentError("setTimeout", 1, "a number of milliseconds of at least 0")
+	}
+
+	// The callback's result settles the promise
+	promise := &LoxPromise{loop: i.loop}
+	i.loop.expect()
+	time.AfterFunc(time.Duration(ms*float64(time.Millisecond)), func() {
+		promise.loop.deliver(func(i *Interpreter) *RuntimeError {
+			promise.resolve(i.call(callback, []interface{}{}, nil))
+			return nil
+		})
+	})
+	return promise, nil
+}
@@ -0,0 +1,73 @@
+package glox_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/alexmarchant/glox"
+)
+
+// defineFetch adds fetch(), which settles its promise from another
+// goroutine with settle.
+func defineFetch(lox *glox.Lox, settle func(promise *glox.LoxPromise)) {
+	i := lox.Interpreter
+	i.DefineNative("fetch", func(args ...glox.Value) (glox.Value, error) {
+		promise := i.NewPromise()
+		go func() {
+			time.Sleep(time.Millisecond)
+			settle(promise)
+		}()
+		return promise, nil
+	})
+}
+
+func TestPromiseResolvedFromGo(t *testing.T) {
+	lox, _ := newLox()
+	defineFetch(lox, func(promise *glox.LoxPromise) {
+		promise.Resolve(42)
+	})
+	run(t, lox, `
+var result = await fetch();
+fun fetched() { return result; }
+`)
+
+	if got := call(t, lox, "fetched"); got != 42.0 {
+		t.Errorf("fetched() = %v, want 42", got)
+	}
+}
+
+func TestPromiseRejectedFromGo(t *testing.T) {
+	lox, _ := newLox()
+	defineFetch(lox, func(promise *glox.LoxPromise) {
+		promise.Reject(errors.New("Connection refused."))
+		// Only the first settle counts
+		promise.Resolve(1)
+	})
+
+	runError(t, lox, "await fetch();", "Connection refused.")
+}
+
+func TestUnhandledRejectionsFailTheScript(t *testing.T) {
+	lox, _ := newLox()
+	defineFetch(lox, func(promise *glox.LoxPromise) {
+		promise.Reject(errors.New("Connection refused."))
+	})
+
+	runError(t, lox, "fetch();", "Connection refused.")
+}
+
+func TestCallWaitsForAsyncFunctions(t *testing.T) {
+	lox, _ := newLox()
+	run(t, lox, `
+fun five() { return 5; }
+async fun slow() {
+  var value = await setTimeout(five, 5);
+  return value + 1;
+}
+`)
+
+	if got := call(t, lox, "slow"); got != 6.0 {
+		t.Errorf("slow() = %v, want 6", got)
+	}
+}
@@ -0,0 +1,49 @@
+package glox
+
+import "fmt"
+
+// LoxRange is a lazy sequence of numbers from range(), counting from
+// Start up to but not including End.
+type LoxRange struct {
+	Start float64
+	End   float64
+	Step  float64
+}
+
+func (r *LoxRange) String() string {
+	return fmt.Sprintf("range(%v, %v, %v)", r.Start, r.End, r.Step)
+}
+
+func (r *LoxRange) includes(n float64) bool {
+	if r.Step > 0 {
+		return n >= r.Start && n < r.End
+	}
+	return n <= r.Start && n > r.End
+}
+
+// range() takes an end, a start and end, or a start, end and step.
+func rangeNative(i *Interpreter, args []interface{}) (interface{}, *RuntimeError) {
+	bounds := []float64{}
+	for idx := range args {
+		n, err := numberArg("range", args, idx)
+		if err != nil {
+			return nil, err
+		}
+		bounds = append(bounds, n)
+	}
+
+	r := &LoxRange{Start: 0, Step: 1}
+	switch len(bounds) {
+	case 1:
+		r.End = bounds[0]
+	case 2:
+		r.Start, r.End = bounds[0], bounds[1]
+	case 3:
+		r.Start, r.End, r.Step = bounds[0], bounds[1], bounds[2]
+	}
+
+	if r.Step == 0 {
+		return nil, &RuntimeError{Message: "Range step cannot be 0."}
+	}
+	return r, nil
+}
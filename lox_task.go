@@ -0,0 +1,140 @@
+package glox
+
+import (
+	"fmt"
+	"sync/atomic"
+)
+
+// LoxTask is a call running on its own goroutine, from spawn. wait()
+// returns its result, or fails with its error. An error nothing waits
+// for fails the script at the end, if the task has finished by then.
+type LoxTask struct {
+	Function LoxCallable
+	finished chan struct{}
+	result   interface{}
+	err      *RuntimeError
+	// Set once wait() is called, so the error isn't lost otherwise
+	waited int32
+}
+
+func (t *LoxTask) String() string {
+	return fmt.Sprintf("<task %s>", frameName(t.Function))
+}
+
+func (t *LoxTask) get(name *Token) (interface{}, *RuntimeError) {
+	switch name.Lexeme {
+	case "wait":
+		return &NativeFunction{
+			Name:      "wait",
+			Signature: Arity{Min: 0, Max: 0},
+			Fn: func(i *Interpreter, args []interface{}) (interface{}, *RuntimeError) {
+				atomic.StoreInt32(&t.waited, 1)
+				select {
+				case <-t.finished:
+				case <-i.done():
+					return nil, i.checkContext()
+				}
+
+				if t.err != nil {
+					// The error's trace shows where the task was
+					// spawned. Waiters get copies, since there can be
+					// more than one.
+					err := *t.err
+					return nil, &err
+				}
+				return t.result, nil
+			},
+		}, nil
+	case "done":
+		return &NativeFunction{
+			Name:      "done",
+			Signature: Arity{Min: 0, Max: 0},
+			Fn: func(i *Interpreter, args []interface{}) (interface{}, *RuntimeError) {
+				select {
+				case <-t.finished:
+					return true, nil
+				default:
+					return false, nil
+				}
+			},
+		}, nil
+	}
+
+	return nil, &RuntimeError{
+		Token:   name,
+		Message: fmt.Sprintf("Undefined property '%s'.", name.Lexeme),
+	}
+}
+
+func (t *LoxTask) set(name *Token, value interface{}) *RuntimeError {
+	return readOnlyError(name, t)
+}
+
+// spawn starts function on a goroutine, on a fork of the interpreter
+// so it has its own call state.
+func (i *Interpreter) spawn(function LoxCallable, args []interface{}, paren *Token) *LoxTask {
+	task := &LoxTask{
+		Function: function,
+		finished: make(chan struct{}),
+	}
+
+	// Tasks run their own event loop, since the loop's tasks can only
+	// run one at a time
+	fork := i.fork()
+	fork.loop = newEventLoop()
+	if i.budget != nil {
+		i.budget.mu.Lock()
+		i.budget.tasks = append(i.budget.tasks, task)
+		i.budget.mu.Unlock()
+	}
+	go func() {
+		defer close(task.finished)
+		task.result, task.err = fork.runTask(function, args, paren)
+	}()
+
+	return task
+}
+
+// runTask calls function, then runs the fork's event loop until the
+// async work it started is done. Tasks of async functions finish with
+// their promise.
+func (i *Interpreter) runTask(function LoxCallable, args []interface{}, paren *Token) (interface{}, *RuntimeError) {
+	result, err := i.call(function, args, paren)
+	if err != nil {
+		return nil, err
+	}
+
+	if promise, ok := result.(*LoxPromise); ok {
+		if err = i.runLoop(promise); err != nil {
+			return nil, err
+		}
+		if result, err = promise.result(); err != nil {
+			return nil, err
+		}
+	}
+	if err = i.runLoop(nil); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// failedTask returns the error of a finished task nothing waited for,
+// which would otherwise be lost. Tasks still running are left alone.
+func (b *budget) failedTask() *RuntimeError {
+	b.mu.Lock()
+	tasks := b.tasks
+	b.mu.Unlock()
+
+	for _, task := range tasks {
+		select {
+		case <-task.finished:
+		default:
+			continue
+		}
+		if task.err != nil && atomic.LoadInt32(&task.waited) == 0 {
+			err := *task.err
+			return &err
+		}
+	}
+	return nil
+}
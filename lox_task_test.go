@@ -0,0 +1,109 @@
+package glox_test
+
+import "testing"
+
+// Run with -race to check tasks share values safely.
+func TestTasksShareValues(t *testing.T) {
+	lox, _ := newLox()
+	run(t, lox, `
+class Counter {}
+
+// Each kind of value gets tasks of its own, so locking on one can't
+// hide races on another. They only use locals, so the shared values
+// are all that's shared.
+fun fillMap(counts, n) {
+  for (var k in range(200)) {
+    counts[k] = n;
+    counts[str(n) + ":" + str(k)] = k;
+    len(counts);
+  }
+}
+fun fillList(slots, n) {
+  for (var k in range(200)) {
+    slots[n] = k;
+    str(slots);
+  }
+}
+fun fillFields(counter, n) {
+  for (var k in range(200)) {
+    counter.last = n;
+    counter.last;
+  }
+}
+
+fun runAll(fill, value) {
+  var tasks = {};
+  for (var n in range(4)) tasks[n] = spawn fill(value, n);
+  for (var n in tasks) tasks[n].wait();
+}
+
+var counts = {};
+var slots = [nil, nil, nil, nil];
+var counter = Counter();
+runAll(fillMap, counts);
+runAll(fillList, slots);
+runAll(fillFields, counter);
+
+fun results() { return str([len(counts), slots]); }
+`)
+
+	if got := call(t, lox, "results"); got != "[1000.000000, [199.000000, 199.000000, 199.000000, 199.000000]]" {
+		t.Errorf("results() = %v", got)
+	}
+}
+
+func TestTasksRunTheirOwnEventLoop(t *testing.T) {
+	lox, _ := newLox()
+	run(t, lox, `
+fun double(x) { return x * 2; }
+async fun later(x) { return await setTimeout(bind(double, x), 1); }
+fun bind(fn, x) {
+  fun bound() { return fn(x); }
+  return bound;
+}
+
+// Async work in tasks runs on the task's loop, alongside main's
+var tasks = {};
+for (var n in range(4)) tasks[n] = spawn later(n);
+var total = await later(10);
+for (var n in tasks) total = total + tasks[n].wait();
+
+// A promise settled on a task's loop can be awaited from main
+var promises = Channel(1);
+fun producer() { promises.send(setTimeout(bind(double, 21), 1)); }
+spawn producer();
+var produced = await promises.recv();
+
+fun results() { return str([total, produced]); }
+`)
+
+	if got := call(t, lox, "results"); got != "[32.000000, 42.000000]" {
+		t.Errorf("results() = %v, want [32, 42]", got)
+	}
+}
+
+func TestUnwaitedTaskErrorsFailTheScript(t *testing.T) {
+	lox, _ := newLox()
+	runError(t, lox, `
+fun broken() { return nil + 1; }
+var task = spawn broken();
+while (!task.done()) {}
+`, "Operands must be two numbers or two strings.")
+
+	// Waited for, the error fails wait() instead
+	runError(t, lox, `
+var other = spawn broken();
+other.wait();
+`, "Operands must be two numbers or two strings.")
+}
+
+func TestWaitGroupStaysUsableAfterGoingNegative(t *testing.T) {
+	lox, _ := newLox()
+	run(t, lox, `
+var group = WaitGroup();
+group.add(1);
+fun work() { group.done(); }
+`)
+	runError(t, lox, "group.add(-2);", "Wait group counter cannot go below 0.")
+	run(t, lox, "spawn work(); group.wait();")
+}
@@ -0,0 +1,130 @@
+package glox_test
+
+import (
+	"bytes"
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/alexmarchant/glox"
+)
+
+// newLox returns a Lox that reports into a buffer instead of stderr.
+func newLox() (*glox.Lox, *bytes.Buffer) {
+	lox := glox.NewLox()
+	stderr := &bytes.Buffer{}
+	lox.Stderr = stderr
+	return lox, stderr
+}
+
+// run runs source, failing the test if it doesn't succeed.
+func run(t *testing.T, lox *glox.Lox, source string) {
+	t.Helper()
+	if err := lox.Run(context.Background(), source); err != nil {
+		t.Fatalf("Run failed: %v", err)
+	}
+}
+
+// runError runs source, which should fail with an error containing
+// message.
+func runError(t *testing.T, lox *glox.Lox, source string, message string) error {
+	t.Helper()
+	err := lox.Run(context.Background(), source)
+	if err == nil {
+		t.Fatalf("Run succeeded, want error containing %q", message)
+	}
+	if !strings.Contains(err.Error(), message) {
+		t.Fatalf("Run error = %q, want it to contain %q", err, message)
+	}
+	return err
+}
+
+// call calls the global function name, failing the test if it doesn't
+// succeed.
+func call(t *testing.T, lox *glox.Lox, name string, args ...interface{}) glox.Value {
+	t.Helper()
+	result, err := lox.Interpreter.Call(name, args...)
+	if err != nil {
+		t.Fatalf("Call(%q) failed: %v", name, err)
+	}
+	return result
+}
+
+func TestRunReportsCompileErrors(t *testing.T) {
+	lox, stderr := newLox()
+	err := runError(t, lox, "var = 1;\nbreak;", "Expect variable name.")
+
+	if _, ok := err.(*glox.CompileError); !ok {
+		t.Errorf("error is %T, want *glox.CompileError", err)
+	}
+	if !strings.Contains(stderr.String(), "[line 1] Error at '=' : Expect variable name.") {
+		t.Errorf("stderr = %q", stderr)
+	}
+	if !lox.HadError {
+		t.Error("HadError not set")
+	}
+}
+
+func TestRunReportsRuntimeErrors(t *testing.T) {
+	lox, stderr := newLox()
+	err := runError(t, lox, "var a = 1;\na + \"x\";", "Operands must be two numbers or two strings.")
+
+	if _, ok := err.(*glox.RuntimeError); !ok {
+		t.Errorf("error is %T, want *glox.RuntimeError", err)
+	}
+	if !strings.Contains(stderr.String(), "[line 2]") {
+		t.Errorf("stderr = %q", stderr)
+	}
+	if !lox.HadRuntimeError {
+		t.Error("HadRuntimeError not set")
+	}
+}
+
+func TestRunKeepsGlobalsBetweenScripts(t *testing.T) {
+	lox, _ := newLox()
+	run(t, lox, "var count = 1;")
+	run(t, lox, "count = count + 1; fun getCount() { return count; }")
+
+	if got := call(t, lox, "getCount"); got != 2.0 {
+		t.Errorf("getCount() = %v, want 2", got)
+	}
+}
+
+func TestRunReturnsExit(t *testing.T) {
+	lox, stderr := newLox()
+	lox.Interpreter.SetGlobal("ran", false)
+	err := lox.Run(context.Background(), `
+fun didRun() { return ran; }
+fun quit() { exit(3); }
+quit();
+ran = true;
+`)
+
+	runtimeErr, ok := err.(*glox.RuntimeError)
+	if !ok || !runtimeErr.IsExit || runtimeErr.ExitCode != 3 {
+		t.Fatalf("Run error = %#v, want exit with status 3", err)
+	}
+	if stderr.Len() > 0 {
+		t.Errorf("exit was reported: %q", stderr)
+	}
+	if call(t, lox, "didRun") != false {
+		t.Error("the script kept running after exit()")
+	}
+}
+
+func TestTailCallsKeepTheCallersFrame(t *testing.T) {
+	lox, stderr := newLox()
+	runError(t, lox, `fun broken(n) { return n + nil; }
+fun loop(n) {
+  if (n == 0) return broken(n);
+  return loop(n - 1);
+}
+fun outer() { var x = loop(3); return x; }
+outer();`, "Operands must be two numbers or two strings.")
+
+	// Tail calls share one frame above the call that started them
+	trace := "[line 1] in broken()\n[line 3] in loop()\n[line 6] in outer()\n[line 7] in script"
+	if !strings.Contains(stderr.String(), trace) {
+		t.Errorf("stderr = %q, want trace %q", stderr, trace)
+	}
+}
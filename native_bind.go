@@ -0,0 +1,297 @@
+package glox
+
+import (
+	"fmt"
+	"reflect"
+)
+
+// Value is any Lox value as seen from Go: nil, bool, float64, string,
+// *LoxList, *LoxMap, *LoxInstance, a callable or *Handle and so on.
+type Value = interface{}
+
+var errorType = reflect.TypeOf((*error)(nil)).Elem()
+
+// DefineNative adds a global native function that takes any number of
+// arguments. A returned error becomes a Lox runtime error.
+func (i *Interpreter) DefineNative(name string, fn func(args ...Value) (Value, error)) {
+	i.Globals.define(name, &NativeFunction{
+		Name:      name,
+		Signature: Arity{Min: 0, Max: 0, Variadic: true},
+		Fn: func(i *Interpreter, args []interface{}) (interface{}, *RuntimeError) {
+			result, err := fn(args...)
+			if err != nil {
+				return nil, &RuntimeError{Message: err.Error()}
+			}
+			return result, nil
+		},
+	})
+}
+
+// Bind adds a global native backed by any Go func. Arguments are
+// converted from Lox to the func's param types, and the results back.
+// The func can return nothing, a value, an error, or a value and an
+// error.
+func (i *Interpreter) Bind(name string, fn interface{}) error {
+	native, err := bindGoFunc(name, reflect.ValueOf(fn))
+	if err != nil {
+		return err
+	}
+	i.Globals.define(name, native)
+	return nil
+}
+
+func bindGoFunc(name string, fn reflect.Value) (*NativeFunction, error) {
+	if !fn.IsValid() {
+		return nil, fmt.Errorf("cannot bind %s: want a func, got nil", name)
+	}
+	fnType := fn.Type()
+	if fnType.Kind() != reflect.Func {
+		return nil, fmt.Errorf("cannot bind %s: want a func, got %s", name, fnType)
+	}
+	if fn.IsNil() {
+		return nil, fmt.Errorf("cannot bind %s: the func is nil", name)
+	}
+
+	numOut := fnType.NumOut()
+	returnsError := numOut > 0 && fnType.Out(numOut-1) == errorType
+	if numOut > 2 || (numOut == 2 && !returnsError) {
+		return nil, fmt.Errorf("cannot bind %s: func must return at most a value and an error", name)
+	}
+
+	arity := Arity{Min: fnType.NumIn(), Max: fnType.NumIn()}
+	if fnType.IsVariadic() {
+		arity = Arity{Min: fnType.NumIn() - 1, Max: fnType.NumIn() - 1, Variadic: true}
+	}
+
+	return &NativeFunction{
+		Name:      name,
+		Signature: arity,
+		Fn: func(i *Interpreter, args []interface{}) (interface{}, *RuntimeError) {
+			in := []reflect.Value{}
+			for idx, arg := range args {
+				paramType := goParamType(fnType, idx)
+
+				// Callbacks need the interpreter to call back into
+				if paramType == handleType {
+					callable, ok := arg.(LoxCallable)
+					if !ok {
+						return nil, argumentError(name, idx, "a function or class")
+					}
+					in = append(in, reflect.ValueOf(&Handle{Interpreter: i, Callable: callable}))
+					continue
+				}
+
+				val, ok := toGoValue(arg, paramType)
+				if !ok {
+					return nil, argumentError(name, idx, goTypeDescription(paramType))
+				}
+				in = append(in, val)
+			}
+
+			out := fn.Call(in)
+			if returnsError {
+				if errVal := out[len(out)-1]; !errVal.IsNil() {
+					return nil, &RuntimeError{Message: errVal.Interface().(error).Error()}
+				}
+				out = out[:len(out)-1]
+			}
+			if len(out) == 0 {
+				return nil, nil
+			}
+			return fromGoValue(out[0]), nil
+		},
+	}, nil
+}
+
+func goParamType(fnType reflect.Type, idx int) reflect.Type {
+	if fnType.IsVariadic() && idx >= fnType.NumIn()-1 {
+		return fnType.In(fnType.NumIn() - 1).Elem()
+	}
+	return fnType.In(idx)
+}
+
+// toGoValue converts a Lox value for a Go param, reporting false if it
+// doesn't fit.
+func toGoValue(val interface{}, t reflect.Type) (reflect.Value, bool) {
+	// Go values passed into the script and back out again
+	if obj, ok := val.(*GoObject); ok {
+		if obj.Value.Type().AssignableTo(t) {
+			return obj.Value, true
+		}
+		if obj.Value.Kind() == reflect.Ptr && obj.Value.Elem().Type().AssignableTo(t) {
+			return obj.Value.Elem(), true
+		}
+		return reflect.Value{}, false
+	}
+
+	if t.Kind() == reflect.Interface {
+		if val == nil {
+			return reflect.Zero(t), true
+		}
+		if !reflect.TypeOf(val).Implements(t) {
+			return reflect.Value{}, false
+		}
+		result := reflect.New(t).Elem()
+		result.Set(reflect.ValueOf(val))
+		return result, true
+	}
+
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		num, ok := val.(float64)
+		result := reflect.New(t).Elem()
+		if !ok || num != float64(int64(num)) || result.OverflowInt(int64(num)) {
+			return reflect.Value{}, false
+		}
+		result.SetInt(int64(num))
+		return result, true
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		num, ok := val.(float64)
+		result := reflect.New(t).Elem()
+		if !ok || num < 0 || num != float64(uint64(num)) || result.OverflowUint(uint64(num)) {
+			return reflect.Value{}, false
+		}
+		result.SetUint(uint64(num))
+		return result, true
+	case reflect.Float32, reflect.Float64:
+		num, ok := val.(float64)
+		if !ok {
+			return reflect.Value{}, false
+		}
+		return reflect.ValueOf(num).Convert(t), true
+	case reflect.String:
+		str, ok := val.(string)
+		if !ok {
+			return reflect.Value{}, false
+		}
+		return reflect.ValueOf(str).Convert(t), true
+	case reflect.Bool:
+		b, ok := val.(bool)
+		if !ok {
+			return reflect.Value{}, false
+		}
+		return reflect.ValueOf(b).Convert(t), true
+	case reflect.Slice:
+		list, ok := val.(*LoxList)
+		if !ok {
+			return reflect.Value{}, false
+		}
+		elements := list.elements()
+		result := reflect.MakeSlice(t, 0, len(elements))
+		for _, element := range elements {
+			elementVal, ok := toGoValue(element, t.Elem())
+			if !ok {
+				return reflect.Value{}, false
+			}
+			result = reflect.Append(result, elementVal)
+		}
+		return result, true
+	case reflect.Map:
+		m, ok := val.(*LoxMap)
+		if !ok {
+			return reflect.Value{}, false
+		}
+		keys := m.keys()
+		result := reflect.MakeMapWithSize(t, len(keys))
+		for _, key := range keys {
+			keyVal, ok := toGoValue(key, t.Key())
+			if !ok {
+				return reflect.Value{}, false
+			}
+			entryVal, ok := toGoValue(m.get(key), t.Elem())
+			if !ok {
+				return reflect.Value{}, false
+			}
+			result.SetMapIndex(keyVal, entryVal)
+		}
+		return result, true
+	}
+
+	return reflect.Value{}, false
+}
+
+// fromGoValue converts a Go value to its Lox equivalent. Structs, and
+// values with no equivalent like funcs and channels, are wrapped in a
+// GoObject.
+func fromGoValue(val reflect.Value) interface{} {
+	if val.IsValid() && val.CanInterface() {
+		if handle, ok := val.Interface().(*Handle); ok && handle != nil {
+			return handle.Callable
+		}
+		if isLoxValue(val.Interface()) {
+			return val.Interface()
+		}
+	}
+
+	switch val.Kind() {
+	case reflect.Invalid:
+		return nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return float64(val.Int())
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return float64(val.Uint())
+	case reflect.Float32, reflect.Float64:
+		return val.Float()
+	case reflect.String:
+		return val.String()
+	case reflect.Bool:
+		return val.Bool()
+	case reflect.Slice, reflect.Array:
+		if val.Kind() == reflect.Slice && val.IsNil() {
+			return nil
+		}
+		elements := []interface{}{}
+		for idx := 0; idx < val.Len(); idx++ {
+			elements = append(elements, fromGoValue(val.Index(idx)))
+		}
+		return &LoxList{Elements: elements}
+	case reflect.Map:
+		if val.IsNil() {
+			return nil
+		}
+		m := NewLoxMap()
+		iter := val.MapRange()
+		for iter.Next() {
+			m.set(fromGoValue(iter.Key()), fromGoValue(iter.Value()))
+		}
+		return m
+	case reflect.Interface, reflect.Ptr:
+		if val.IsNil() {
+			return nil
+		}
+		if val.Kind() == reflect.Interface {
+			return fromGoValue(val.Elem())
+		}
+	}
+
+	return &GoObject{Value: val}
+}
+
+// isLoxValue reports whether val is already a Lox value, so it doesn't
+// need converting.
+func isLoxValue(val interface{}) bool {
+	switch val.(type) {
+	case *LoxList, *LoxMap, LoxObject, LoxCallable:
+		return true
+	}
+	return false
+}
+
+func goTypeDescription(t reflect.Type) string {
+	switch t.Kind() {
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return "an integer"
+	case reflect.Float32, reflect.Float64:
+		return "a number"
+	case reflect.String:
+		return "a string"
+	case reflect.Bool:
+		return "a bool"
+	case reflect.Slice:
+		return fmt.Sprintf("a list of %s values", t.Elem())
+	case reflect.Map:
+		return fmt.Sprintf("a map of %s to %s values", t.Key(), t.Elem())
+	}
+	return fmt.Sprintf("a %s", t)
+}
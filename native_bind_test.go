@@ -0,0 +1,137 @@
+package glox_test
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+
+	"github.com/alexmarchant/glox"
+)
+
+func TestDefineNative(t *testing.T) {
+	lox, _ := newLox()
+	lox.Interpreter.DefineNative("sum", func(args ...glox.Value) (glox.Value, error) {
+		total := 0.0
+		for _, arg := range args {
+			num, ok := arg.(float64)
+			if !ok {
+				return nil, errors.New("sum() takes numbers.")
+			}
+			total += num
+		}
+		return total, nil
+	})
+	run(t, lox, "fun total() { return sum(1, 2, 3); }")
+
+	if got := call(t, lox, "total"); got != 6.0 {
+		t.Errorf("total() = %v, want 6", got)
+	}
+	runError(t, lox, `sum(1, "2");`, "sum() takes numbers.")
+}
+
+func TestBindConvertsArguments(t *testing.T) {
+	lox, _ := newLox()
+	var gotCount int
+	var gotName string
+	var gotScores []float64
+	var gotAges map[string]int
+	err := lox.Interpreter.Bind("record", func(count int, name string, scores []float64, ages map[string]int) string {
+		gotCount, gotName, gotScores, gotAges = count, name, scores, ages
+		return name + "!"
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	run(t, lox, `fun go() { return record(3, "lox", [1.5, 2], {"ann": 30}); }`)
+
+	if got := call(t, lox, "go"); got != "lox!" {
+		t.Errorf("go() = %v, want lox!", got)
+	}
+	if gotCount != 3 || gotName != "lox" {
+		t.Errorf("got count %d and name %q", gotCount, gotName)
+	}
+	if !reflect.DeepEqual(gotScores, []float64{1.5, 2}) {
+		t.Errorf("got scores %v", gotScores)
+	}
+	if !reflect.DeepEqual(gotAges, map[string]int{"ann": 30}) {
+		t.Errorf("got ages %v", gotAges)
+	}
+
+	runError(t, lox, `record(1.5, "lox", [], {});`, "Argument 1 to record() must be an integer.")
+	runError(t, lox, `record(1, "lox", ["a"], {});`, "Argument 3 to record() must be a list of float64 values.")
+	runError(t, lox, `record(1, "lox");`, "Expected 4 arguments but got 2.")
+}
+
+func TestBindReturnsErrors(t *testing.T) {
+	lox, _ := newLox()
+	err := lox.Interpreter.Bind("parse", func(s string) (int, error) {
+		if s == "" {
+			return 0, errors.New("Nothing to parse.")
+		}
+		return len(s), nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	run(t, lox, `fun parsed() { return parse("abc"); }`)
+
+	if got := call(t, lox, "parsed"); got != 3.0 {
+		t.Errorf("parsed() = %v, want 3", got)
+	}
+	runError(t, lox, `parse("");`, "Nothing to parse.")
+}
+
+func TestBindVariadic(t *testing.T) {
+	lox, _ := newLox()
+	err := lox.Interpreter.Bind("join", func(sep string, parts ...string) string {
+		result := ""
+		for idx, part := range parts {
+			if idx > 0 {
+				result += sep
+			}
+			result += part
+		}
+		return result
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	run(t, lox, `fun joined() { return join("-", "a", "b", "c"); }`)
+
+	if got := call(t, lox, "joined"); got != "a-b-c" {
+		t.Errorf("joined() = %v, want a-b-c", got)
+	}
+}
+
+func TestBindRejectsBadFuncs(t *testing.T) {
+	lox, _ := newLox()
+	tests := []struct {
+		name    string
+		fn      interface{}
+		message string
+	}{
+		{"notFunc", 42, "cannot bind notFunc: want a func, got int"},
+		{"untyped", nil, "cannot bind untyped: want a func, got nil"},
+		{"nilFunc", (func())(nil), "cannot bind nilFunc: the func is nil"},
+	}
+	for _, test := range tests {
+		err := lox.Interpreter.Bind(test.name, test.fn)
+		if err == nil || err.Error() != test.message {
+			t.Errorf("Bind(%q) error = %v, want %q", test.name, err, test.message)
+		}
+	}
+	if err := lox.Interpreter.Bind("twoValues", func() (int, int) { return 1, 2 }); err == nil {
+		t.Error("binding a func returning two values succeeded")
+	}
+}
+
+func TestUnconvertibleValuesAreGoObjects(t *testing.T) {
+	lox, _ := newLox()
+	lox.Interpreter.SetGlobal("callback", func() {})
+	lox.Interpreter.SetGlobal("events", make(chan int))
+	run(t, lox, `fun check() { return type(callback) + ":" + str(callback == events); }`)
+
+	if got := call(t, lox, "check"); got != "instance:false" {
+		t.Errorf("check() = %v, want instance:false", got)
+	}
+}
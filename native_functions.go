@@ -1,7 +1,8 @@
-package main
+package glox
 
 import (
 	"fmt"
+	"strings"
 	"time"
 )
 
@@ -13,8 +14,8 @@ func (f *ClockNativeFunc) Call(i *Interpreter, args []interface{}) (interface{},
 	return float64(val), nil
 }
 
-func (f *ClockNativeFunc) Arity() int {
-	return 0
+func (f *ClockNativeFunc) Arity() Arity {
+	return Arity{Min: 0, Max: 0}
 }
 
 func (f *ClockNativeFunc) String() string {
@@ -25,14 +26,75 @@ func (f *ClockNativeFunc) String() string {
 type PrintNativeFunc struct{}
 
 func (f *PrintNativeFunc) Call(i *Interpreter, args []interface{}) (interface{}, *RuntimeError) {
-	fmt.Printf("%s\n", i.stringify(args[0]))
+	strs := []string{}
+	for _, arg := range args {
+		strs = append(strs, i.stringify(arg))
+	}
+	fmt.Printf("%s\n", strings.Join(strs, " "))
 	return nil, nil
 }
 
-func (f *PrintNativeFunc) Arity() int {
-	return 1
+func (f *PrintNativeFunc) Arity() Arity {
+	return Arity{Min: 0, Max: 0, Variadic: true}
 }
 
 func (f *PrintNativeFunc) String() string {
 	return "<native fn>"
 }
+
+// NativeFunction is a native backed by a plain Go func, for natives
+// that don't need a type of their own.
+type NativeFunction struct {
+	Name      string
+	Signature Arity
+	Fn        func(*Interpreter, []interface{}) (interface{}, *RuntimeError)
+}
+
+func (f *NativeFunction) Call(i *Interpreter, args []interface{}) (interface{}, *RuntimeError) {
+	return f.Fn(i, args)
+}
+
+func (f *NativeFunction) Arity() Arity {
+	return f.Signature
+}
+
+func (f *NativeFunction) String() string {
+	return "<native fn>"
+}
+
+// Argument helpers. Natives report errors without a token, the call
+// site fills it in.
+
+func stringArg(native string, args []interface{}, idx int) (string, *RuntimeError) {
+	if str, ok := args[idx].(string); ok {
+		return str, nil
+	}
+	return "", argumentError(native, idx, "a string")
+}
+
+func numberArg(native string, args []interface{}, idx int) (float64, *RuntimeError) {
+	if num, ok := args[idx].(float64); ok {
+		return num, nil
+	}
+	return 0, argumentError(native, idx, "a number")
+}
+
+func intArg(native string, args []interface{}, idx int) (int, *RuntimeError) {
+	if num, ok := args[idx].(float64); ok && num == float64(int(num)) {
+		return int(num), nil
+	}
+	return 0, argumentError(native, idx, "an integer")
+}
+
+func listArg(native string, args []interface{}, idx int) (*LoxList, *RuntimeError) {
+	if list, ok := args[idx].(*LoxList); ok {
+		return list, nil
+	}
+	return nil, argumentError(native, idx, "a list")
+}
+
+func argumentError(native string, idx int, expected string) *RuntimeError {
+	return &RuntimeError{
+		Message: fmt.Sprintf("Argument %d to %s() must be %s.", idx+1, native, expected),
+	}
+}
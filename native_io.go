@@ -0,0 +1,193 @@
+package glox
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"sort"
+	"strings"
+)
+
+// File and OS natives. Embedders running untrusted scripts can remove
+// them all with Interpreter.DisableIO.
+var ioNatives = []*NativeFunction{
+	{Name: "readFile", Signature: Arity{Min: 1, Max: 1}, Fn: readFileNative},
+	{Name: "writeFile", Signature: Arity{Min: 2, Max: 2}, Fn: writeFileNative},
+	{Name: "appendFile", Signature: Arity{Min: 2, Max: 2}, Fn: appendFileNative},
+	{Name: "listDir", Signature: Arity{Min: 1, Max: 1}, Fn: listDirNative},
+	{Name: "exists", Signature: Arity{Min: 1, Max: 1}, Fn: existsNative},
+	{Name: "remove", Signature: Arity{Min: 1, Max: 1}, Fn: removeNative},
+	{Name: "readLine", Signature: Arity{Min: 0, Max: 0}, Fn: readLineNative},
+	{Name: "args", Signature: Arity{Min: 0, Max: 0}, Fn: argsNative},
+	{Name: "env", Signature: Arity{Min: 1, Max: 1}, Fn: envNative},
+	{Name: "exit", Signature: Arity{Min: 0, Max: 1}, Fn: exitNative},
+}
+
+// readFile()
+func readFileNative(i *Interpreter, args []interface{}) (interface{}, *RuntimeError) {
+	path, err := stringArg("readFile", args, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	bytes, ioErr := ioutil.ReadFile(path)
+	if ioErr != nil {
+		return nil, &RuntimeError{Message: ioErr.Error()}
+	}
+	return string(bytes), nil
+}
+
+// writeFile()
+func writeFileNative(i *Interpreter, args []interface{}) (interface{}, *RuntimeError) {
+	path, err := stringArg("writeFile", args, 0)
+	if err != nil {
+		return nil, err
+	}
+	content, err := stringArg("writeFile", args, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	ioErr := ioutil.WriteFile(path, []byte(content), 0644)
+	if ioErr != nil {
+		return nil, &RuntimeError{Message: ioErr.Error()}
+	}
+	return nil, nil
+}
+
+// appendFile()
+func appendFileNative(i *Interpreter, args []interface{}) (interface{}, *RuntimeError) {
+	path, err := stringArg("appendFile", args, 0)
+	if err != nil {
+		return nil, err
+	}
+	content, err := stringArg("appendFile", args, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	file, ioErr := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if ioErr != nil {
+		return nil, &RuntimeError{Message: ioErr.Error()}
+	}
+	defer file.Close()
+
+	_, ioErr = file.WriteString(content)
+	if ioErr != nil {
+		return nil, &RuntimeError{Message: ioErr.Error()}
+	}
+	return nil, nil
+}
+
+// listDir()
+func listDirNative(i *Interpreter, args []interface{}) (interface{}, *RuntimeError) {
+	path, err := stringArg("listDir", args, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	infos, ioErr := ioutil.ReadDir(path)
+	if ioErr != nil {
+		return nil, &RuntimeError{Message: ioErr.Error()}
+	}
+
+	names := []string{}
+	for _, info := range infos {
+		names = append(names, info.Name())
+	}
+	sort.Strings(names)
+
+	elements := []interface{}{}
+	for _, name := range names {
+		elements = append(elements, name)
+	}
+	return &LoxList{Elements: elements}, nil
+}
+
+// exists()
+func existsNative(i *Interpreter, args []interface{}) (interface{}, *RuntimeError) {
+	path, err := stringArg("exists", args, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	_, ioErr := os.Stat(path)
+	return ioErr == nil, nil
+}
+
+// remove()
+func removeNative(i *Interpreter, args []interface{}) (interface{}, *RuntimeError) {
+	path, err := stringArg("remove", args, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	ioErr := os.Remove(path)
+	if ioErr != nil {
+		return nil, &RuntimeError{Message: ioErr.Error()}
+	}
+	return nil, nil
+}
+
+// readLine() returns nil once stdin is exhausted.
+func readLineNative(i *Interpreter, args []interface{}) (interface{}, *RuntimeError) {
+	i.stdinMu.Lock()
+	line, ioErr := i.Stdin.ReadString('\n')
+	i.stdinMu.Unlock()
+	if ioErr == io.EOF && line == "" {
+		return nil, nil
+	}
+	if ioErr != nil && ioErr != io.EOF {
+		return nil, &RuntimeError{Message: ioErr.Error()}
+	}
+
+	line = strings.TrimSuffix(line, "\n")
+	line = strings.TrimSuffix(line, "\r")
+	return line, nil
+}
+
+// args()
+func argsNative(i *Interpreter, args []interface{}) (interface{}, *RuntimeError) {
+	elements := []interface{}{}
+	for _, arg := range i.Args {
+		elements = append(elements, arg)
+	}
+	return &LoxList{Elements: elements}, nil
+}
+
+// env() returns nil for unset variables.
+func envNative(i *Interpreter, args []interface{}) (interface{}, *RuntimeError) {
+	name, err := stringArg("env", args, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	if val, ok := os.LookupEnv(name); ok {
+		return val, nil
+	}
+	return nil, nil
+}
+
+// exit()
+func exitNative(i *Interpreter, args []interface{}) (interface{}, *RuntimeError) {
+	code := 0
+	if len(args) > 0 {
+		var err *RuntimeError
+		code, err = intArg("exit", args, 0)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	err := &RuntimeError{
+		Message:  fmt.Sprintf("Exited with status %d.", code),
+		IsExit:   true,
+		ExitCode: code,
+	}
+	// Anything else running stops too, like it does at a limit
+	if i.budget != nil {
+		return nil, i.budget.fail(err)
+	}
+	return nil, err
+}
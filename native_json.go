@@ -0,0 +1,252 @@
+package glox
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+var jsonModule = &LoxModule{
+	Name: "json",
+	Members: map[string]interface{}{
+		"parse":     &NativeFunction{Name: "json.parse", Signature: Arity{Min: 1, Max: 1}, Fn: jsonParseNative},
+		"stringify": &NativeFunction{Name: "json.stringify", Signature: Arity{Min: 1, Max: 2}, Fn: jsonStringifyNative},
+	},
+}
+
+// json.parse()
+func jsonParseNative(i *Interpreter, args []interface{}) (interface{}, *RuntimeError) {
+	text, err := stringArg("json.parse", args, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	decoder := json.NewDecoder(strings.NewReader(text))
+	decoder.UseNumber()
+
+	value, jsonErr := decodeJSONValue(decoder)
+	offset := decoder.InputOffset()
+	if jsonErr == nil {
+		// Only whitespace may follow the value
+		rest := text[offset:]
+		if trimmed := strings.TrimLeft(rest, " \t\r\n"); trimmed != "" {
+			offset += int64(len(rest) - len(trimmed))
+			jsonErr = fmt.Errorf("unexpected data after JSON value")
+		}
+	}
+	if jsonErr != nil {
+		// Syntax errors are reported after the offending byte
+		if syntaxErr, ok := jsonErr.(*json.SyntaxError); ok && syntaxErr.Offset > 0 {
+			offset = syntaxErr.Offset - 1
+		}
+		line, column := textPosition(text, offset)
+		msg := fmt.Sprintf("Invalid JSON at line %d, column %d: %s.", line, column, jsonErr)
+		return nil, &RuntimeError{Message: msg}
+	}
+
+	return value, nil
+}
+
+// decodeJSONValue reads the decoder token by token rather than
+// unmarshaling, so object keys keep their order.
+func decodeJSONValue(decoder *json.Decoder) (interface{}, error) {
+	token, err := decoder.Token()
+	if err == io.EOF {
+		return nil, io.ErrUnexpectedEOF
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	switch val := token.(type) {
+	case json.Delim:
+		if val == '[' {
+			list := &LoxList{Elements: []interface{}{}}
+			for decoder.More() {
+				element, err := decodeJSONValue(decoder)
+				if err != nil {
+					return nil, err
+				}
+				list.Elements = append(list.Elements, element)
+			}
+			_, err = decoder.Token()
+			return list, err
+		}
+
+		m := NewLoxMap()
+		for decoder.More() {
+			key, err := decoder.Token()
+			if err != nil {
+				return nil, err
+			}
+			value, err := decodeJSONValue(decoder)
+			if err != nil {
+				return nil, err
+			}
+			m.set(key, value)
+		}
+		_, err = decoder.Token()
+		return m, err
+	case json.Number:
+		return val.Float64()
+	default:
+		// string, bool or nil
+		return val, nil
+	}
+}
+
+func textPosition(text string, offset int64) (int, int) {
+	if offset > int64(len(text)) {
+		offset = int64(len(text))
+	}
+
+	before := text[:offset]
+	line := strings.Count(before, "\n") + 1
+	column := len(before) - strings.LastIndex(before, "\n")
+	return line, column
+}
+
+// json.stringify()
+func jsonStringifyNative(i *Interpreter, args []interface{}) (interface{}, *RuntimeError) {
+	indent := 0
+	if len(args) > 1 {
+		var err *RuntimeError
+		indent, err = intArg("json.stringify", args, 1)
+		if err != nil {
+			return nil, err
+		}
+		if indent < 0 {
+			return nil, argumentError("json.stringify", 1, "an indent of at least 0")
+		}
+		if err := i.checkStringSize(nil, indent); err != nil {
+			return nil, err
+		}
+	}
+
+	encoder := &jsonEncoder{
+		indent:   strings.Repeat(" ", indent),
+		visiting: map[interface{}]bool{},
+	}
+	err := encoder.encode(args[0], 0)
+	if err != nil {
+		return nil, err
+	}
+	return encoder.out.String(), nil
+}
+
+type jsonEncoder struct {
+	out      strings.Builder
+	indent   string
+	visiting map[interface{}]bool
+}
+
+func (e *jsonEncoder) encode(val interface{}, depth int) *RuntimeError {
+	switch v := val.(type) {
+	case nil:
+		e.out.WriteString("null")
+	case bool:
+		e.out.WriteString(strconv.FormatBool(v))
+	case float64:
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return &RuntimeError{Message: fmt.Sprintf("Cannot convert %v to JSON.", v)}
+		}
+		e.out.WriteString(strconv.FormatFloat(v, 'f', -1, 64))
+	case string:
+		e.writeString(v)
+	case *LoxList:
+		if e.visiting[v] {
+			return &RuntimeError{Message: "Cannot convert a list that contains itself to JSON."}
+		}
+		e.visiting[v] = true
+		defer delete(e.visiting, v)
+
+		elements := v.elements()
+		e.out.WriteString("[")
+		for idx, element := range elements {
+			if idx > 0 {
+				e.out.WriteString(",")
+			}
+			e.newline(depth + 1)
+			if err := e.encode(element, depth+1); err != nil {
+				return err
+			}
+		}
+		if len(elements) > 0 {
+			e.newline(depth)
+		}
+		e.out.WriteString("]")
+	case *LoxMap:
+		if e.visiting[v] {
+			return &RuntimeError{Message: "Cannot convert a map that contains itself to JSON."}
+		}
+		e.visiting[v] = true
+		defer delete(e.visiting, v)
+
+		keys := []string{}
+		for _, key := range v.keys() {
+			strKey, ok := key.(string)
+			if !ok {
+				return &RuntimeError{Message: "Cannot convert a map with non-string keys to JSON."}
+			}
+			keys = append(keys, strKey)
+		}
+		return e.encodeObject(keys, func(key string) interface{} { return v.get(key) }, depth)
+	case *LoxInstance:
+		if e.visiting[v] {
+			return &RuntimeError{Message: "Cannot convert an instance that refers to itself to JSON."}
+		}
+		e.visiting[v] = true
+		defer delete(e.visiting, v)
+
+		return e.encodeObject(v.fieldNames(), func(key string) interface{} {
+			val, _ := v.field(key)
+			return val
+		}, depth)
+	default:
+		return &RuntimeError{Message: fmt.Sprintf("Cannot convert %s to JSON.", typeName(val))}
+	}
+
+	return nil
+}
+
+func (e *jsonEncoder) encodeObject(keys []string, value func(string) interface{}, depth int) *RuntimeError {
+	e.out.WriteString("{")
+	for idx, key := range keys {
+		if idx > 0 {
+			e.out.WriteString(",")
+		}
+		e.newline(depth + 1)
+		e.writeString(key)
+		e.out.WriteString(":")
+		if e.indent != "" {
+			e.out.WriteString(" ")
+		}
+		if err := e.encode(value(key), depth+1); err != nil {
+			return err
+		}
+	}
+	if len(keys) > 0 {
+		e.newline(depth)
+	}
+	e.out.WriteString("}")
+	return nil
+}
+
+func (e *jsonEncoder) writeString(str string) {
+	var buf strings.Builder
+	encoder := json.NewEncoder(&buf)
+	encoder.SetEscapeHTML(false)
+	_ = encoder.Encode(str)
+	e.out.WriteString(strings.TrimSuffix(buf.String(), "\n"))
+}
+
+func (e *jsonEncoder) newline(depth int) {
+	if e.indent == "" {
+		return
+	}
+	e.out.WriteString("\n")
+	e.out.WriteString(strings.Repeat(e.indent, depth))
+}
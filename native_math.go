@@ -0,0 +1,154 @@
+package glox
+
+import (
+	"fmt"
+	"math"
+	"math/rand"
+	"sync"
+)
+
+var mathModule = &LoxModule{
+	Name: "math",
+	Members: map[string]interface{}{
+		"PI":        math.Pi,
+		"E":         math.E,
+		"INF":       math.Inf(1),
+		"NAN":       math.NaN(),
+		"sqrt":      unaryMathNative("sqrt", math.Sqrt),
+		"abs":       unaryMathNative("abs", math.Abs),
+		"floor":     unaryMathNative("floor", math.Floor),
+		"ceil":      unaryMathNative("ceil", math.Ceil),
+		"round":     unaryMathNative("round", math.Round),
+		"sin":       unaryMathNative("sin", math.Sin),
+		"cos":       unaryMathNative("cos", math.Cos),
+		"tan":       unaryMathNative("tan", math.Tan),
+		"asin":      unaryMathNative("asin", math.Asin),
+		"acos":      unaryMathNative("acos", math.Acos),
+		"atan":      unaryMathNative("atan", math.Atan),
+		"log":       unaryMathNative("log", math.Log),
+		"exp":       unaryMathNative("exp", math.Exp),
+		"pow":       binaryMathNative("pow", math.Pow),
+		"atan2":     binaryMathNative("atan2", math.Atan2),
+		"min":       &NativeFunction{Name: "math.min", Signature: Arity{Min: 1, Max: 1, Variadic: true}, Fn: minNative},
+		"max":       &NativeFunction{Name: "math.max", Signature: Arity{Min: 1, Max: 1, Variadic: true}, Fn: maxNative},
+		"random":    &NativeFunction{Name: "math.random", Signature: Arity{Min: 0, Max: 0}, Fn: randomNative},
+		"randomInt": &NativeFunction{Name: "math.randomInt", Signature: Arity{Min: 2, Max: 2}, Fn: randomIntNative},
+		"seed":      &NativeFunction{Name: "math.seed", Signature: Arity{Min: 1, Max: 1}, Fn: seedNative},
+	},
+}
+
+func unaryMathNative(name string, fn func(float64) float64) *NativeFunction {
+	name = "math." + name
+	return &NativeFunction{
+		Name:      name,
+		Signature: Arity{Min: 1, Max: 1},
+		Fn: func(i *Interpreter, args []interface{}) (interface{}, *RuntimeError) {
+			x, err := numberArg(name, args, 0)
+			if err != nil {
+				return nil, err
+			}
+			return fn(x), nil
+		},
+	}
+}
+
+func binaryMathNative(name string, fn func(float64, float64) float64) *NativeFunction {
+	name = "math." + name
+	return &NativeFunction{
+		Name:      name,
+		Signature: Arity{Min: 2, Max: 2},
+		Fn: func(i *Interpreter, args []interface{}) (interface{}, *RuntimeError) {
+			x, err := numberArg(name, args, 0)
+			if err != nil {
+				return nil, err
+			}
+			y, err := numberArg(name, args, 1)
+			if err != nil {
+				return nil, err
+			}
+			return fn(x, y), nil
+		},
+	}
+}
+
+// math.min()
+func minNative(i *Interpreter, args []interface{}) (interface{}, *RuntimeError) {
+	return foldNumbers("math.min", args, math.Min)
+}
+
+// math.max()
+func maxNative(i *Interpreter, args []interface{}) (interface{}, *RuntimeError) {
+	return foldNumbers("math.max", args, math.Max)
+}
+
+func foldNumbers(native string, args []interface{}, fn func(float64, float64) float64) (interface{}, *RuntimeError) {
+	result, err := numberArg(native, args, 0)
+	if err != nil {
+		return nil, err
+	}
+	for idx := 1; idx < len(args); idx++ {
+		num, err := numberArg(native, args, idx)
+		if err != nil {
+			return nil, err
+		}
+		result = fn(result, num)
+	}
+	return result, nil
+}
+
+// math.random()
+func randomNative(i *Interpreter, args []interface{}) (interface{}, *RuntimeError) {
+	return i.Random.Float64(), nil
+}
+
+// math.randomInt()
+func randomIntNative(i *Interpreter, args []interface{}) (interface{}, *RuntimeError) {
+	low, err := intArg("math.randomInt", args, 0)
+	if err != nil {
+		return nil, err
+	}
+	high, err := intArg("math.randomInt", args, 1)
+	if err != nil {
+		return nil, err
+	}
+	// Both ends are inclusive, and the range has to fit in an int
+	size := high - low + 1
+	if low > high || size <= 0 {
+		msg := fmt.Sprintf("Invalid range %d to %d for math.randomInt().", low, high)
+		return nil, &RuntimeError{Message: msg}
+	}
+
+	return float64(low + i.Random.Intn(size)), nil
+}
+
+// math.seed()
+func seedNative(i *Interpreter, args []interface{}) (interface{}, *RuntimeError) {
+	seed, err := intArg("math.seed", args, 0)
+	if err != nil {
+		return nil, err
+	}
+	i.Random.Seed(int64(seed))
+	return nil, nil
+}
+
+// lockedSource lets tasks share the interpreter's Random.
+type lockedSource struct {
+	mu  sync.Mutex
+	src rand.Source
+}
+
+func newLockedSource(seed int64) *lockedSource {
+	return &lockedSource{src: rand.NewSource(seed)}
+}
+
+func (s *lockedSource) Int63() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.src.Int63()
+}
+
+func (s *lockedSource) Seed(seed int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.src.Seed(seed)
+}
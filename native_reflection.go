@@ -0,0 +1,213 @@
+package glox
+
+import (
+	"fmt"
+	"sort"
+)
+
+// typeName returns the name type() reports for a Lox value.
+func typeName(val interface{}) string {
+	switch val.(type) {
+	case nil:
+		return "nil"
+	case bool:
+		return "bool"
+	case float64:
+		return "number"
+	case string:
+		return "string"
+	case *LoxList:
+		return "list"
+	case *LoxMap:
+		return "map"
+	case *LoxModule:
+		return "module"
+	case *LoxRegex:
+		return "regex"
+	case *LoxTime:
+		return "time"
+	case *LoxGenerator:
+		return "generator"
+	case *LoxRange:
+		return "range"
+	case *LoxTask:
+		return "task"
+	case *LoxChannel:
+		return "channel"
+	case *LoxWaitGroup:
+		return "waitgroup"
+	case *LoxPromise:
+		return "promise"
+	case *LoxClass:
+		return "class"
+	case *LoxInstance, *GoObject:
+		return "instance"
+	case LoxCallable:
+		return "function"
+	default:
+		return "unknown"
+	}
+}
+
+// type()
+type TypeNativeFunc struct{}
+
+func (f *TypeNativeFunc) Call(i *Interpreter, args []interface{}) (interface{}, *RuntimeError) {
+	return typeName(args[0]), nil
+}
+
+func (f *TypeNativeFunc) Arity() Arity {
+	return Arity{Min: 1, Max: 1}
+}
+
+func (f *TypeNativeFunc) String() string {
+	return "<native fn>"
+}
+
+// fields()
+type FieldsNativeFunc struct{}
+
+func (f *FieldsNativeFunc) Call(i *Interpreter, args []interface{}) (interface{}, *RuntimeError) {
+	instance, ok := args[0].(*LoxInstance)
+	if !ok {
+		return nil, &RuntimeError{Message: "Argument to fields() must be an instance."}
+	}
+
+	return sortedNameList(instance.fieldNames()), nil
+}
+
+func (f *FieldsNativeFunc) Arity() Arity {
+	return Arity{Min: 1, Max: 1}
+}
+
+func (f *FieldsNativeFunc) String() string {
+	return "<native fn>"
+}
+
+// methods()
+type MethodsNativeFunc struct{}
+
+func (f *MethodsNativeFunc) Call(i *Interpreter, args []interface{}) (interface{}, *RuntimeError) {
+	class, ok := args[0].(*LoxClass)
+	if !ok {
+		return nil, &RuntimeError{Message: "Argument to methods() must be a class."}
+	}
+
+	// Include inherited methods, but only list overridden ones once
+	seen := map[string]bool{}
+	names := []string{}
+	for ; class != nil; class = class.Superclass {
+		for name := range class.Methods {
+			if !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+
+	return sortedNameList(names), nil
+}
+
+func (f *MethodsNativeFunc) Arity() Arity {
+	return Arity{Min: 1, Max: 1}
+}
+
+func (f *MethodsNativeFunc) String() string {
+	return "<native fn>"
+}
+
+// superclass()
+type SuperclassNativeFunc struct{}
+
+func (f *SuperclassNativeFunc) Call(i *Interpreter, args []interface{}) (interface{}, *RuntimeError) {
+	class, ok := args[0].(*LoxClass)
+	if !ok {
+		return nil, &RuntimeError{Message: "Argument to superclass() must be a class."}
+	}
+
+	if class.Superclass == nil {
+		return nil, nil
+	}
+	return class.Superclass, nil
+}
+
+func (f *SuperclassNativeFunc) Arity() Arity {
+	return Arity{Min: 1, Max: 1}
+}
+
+func (f *SuperclassNativeFunc) String() string {
+	return "<native fn>"
+}
+
+// hasField()
+type HasFieldNativeFunc struct{}
+
+func (f *HasFieldNativeFunc) Call(i *Interpreter, args []interface{}) (interface{}, *RuntimeError) {
+	instance, name, err := instanceAndFieldName("hasField", args)
+	if err != nil {
+		return nil, err
+	}
+
+	_, ok := instance.field(name)
+	return ok, nil
+}
+
+func (f *HasFieldNativeFunc) Arity() Arity {
+	return Arity{Min: 2, Max: 2}
+}
+
+func (f *HasFieldNativeFunc) String() string {
+	return "<native fn>"
+}
+
+// getField()
+type GetFieldNativeFunc struct{}
+
+func (f *GetFieldNativeFunc) Call(i *Interpreter, args []interface{}) (interface{}, *RuntimeError) {
+	instance, name, err := instanceAndFieldName("getField", args)
+	if err != nil {
+		return nil, err
+	}
+
+	val, ok := instance.field(name)
+	if !ok {
+		return nil, &RuntimeError{Message: fmt.Sprintf("Undefined field '%s'.", name)}
+	}
+	return val, nil
+}
+
+func (f *GetFieldNativeFunc) Arity() Arity {
+	return Arity{Min: 2, Max: 2}
+}
+
+func (f *GetFieldNativeFunc) String() string {
+	return "<native fn>"
+}
+
+// Helpers
+
+func instanceAndFieldName(native string, args []interface{}) (*LoxInstance, string, *RuntimeError) {
+	instance, ok := args[0].(*LoxInstance)
+	if !ok {
+		msg := fmt.Sprintf("First argument to %s() must be an instance.", native)
+		return nil, "", &RuntimeError{Message: msg}
+	}
+
+	name, ok := args[1].(string)
+	if !ok {
+		msg := fmt.Sprintf("Second argument to %s() must be a string.", native)
+		return nil, "", &RuntimeError{Message: msg}
+	}
+
+	return instance, name, nil
+}
+
+func sortedNameList(names []string) *LoxList {
+	sort.Strings(names)
+
+	elements := []interface{}{}
+	for _, name := range names {
+		elements = append(elements, name)
+	}
+	return &LoxList{Elements: elements}
+}
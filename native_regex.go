@@ -0,0 +1,169 @@
+package glox
+
+import (
+	"fmt"
+	"regexp"
+)
+
+// LoxRegex is a compiled pattern from regex.compile(). It has the same
+// methods as the regex module, minus the pattern argument.
+type LoxRegex struct {
+	Regexp *regexp.Regexp
+}
+
+func (r *LoxRegex) String() string {
+	return fmt.Sprintf("<regex %s>", r.Regexp)
+}
+
+func (r *LoxRegex) get(name *Token) (interface{}, *RuntimeError) {
+	for _, op := range regexOperations {
+		if op.name == name.Lexeme {
+			return r.method(op), nil
+		}
+	}
+
+	return nil, &RuntimeError{
+		Token:   name,
+		Message: fmt.Sprintf("Undefined property '%s'.", name.Lexeme),
+	}
+}
+
+func (r *LoxRegex) set(name *Token, value interface{}) *RuntimeError {
+	return readOnlyError(name, r)
+}
+
+func (r *LoxRegex) method(op regexOperation) *NativeFunction {
+	return &NativeFunction{
+		Name:      op.name,
+		Signature: Arity{Min: op.arity, Max: op.arity},
+		Fn: func(i *Interpreter, args []interface{}) (interface{}, *RuntimeError) {
+			return op.fn(op.name, r.Regexp, args, 0)
+		},
+	}
+}
+
+// regexOperation is shared by the module functions and pattern
+// methods. Its args start at first, after the pattern if there is one.
+type regexOperation struct {
+	name  string
+	arity int
+	fn    func(native string, re *regexp.Regexp, args []interface{}, first int) (interface{}, *RuntimeError)
+}
+
+var regexOperations = []regexOperation{
+	{name: "match", arity: 1, fn: regexMatch},
+	{name: "find", arity: 1, fn: regexFind},
+	{name: "findAll", arity: 1, fn: regexFindAll},
+	{name: "replace", arity: 2, fn: regexReplace},
+	{name: "split", arity: 1, fn: regexSplit},
+}
+
+var regexModule = newRegexModule()
+
+func newRegexModule() *LoxModule {
+	members := map[string]interface{}{
+		"compile": &NativeFunction{Name: "regex.compile", Signature: Arity{Min: 1, Max: 1}, Fn: regexCompileNative},
+	}
+
+	for _, op := range regexOperations {
+		op := op
+		native := "regex." + op.name
+		members[op.name] = &NativeFunction{
+			Name:      native,
+			Signature: Arity{Min: op.arity + 1, Max: op.arity + 1},
+			Fn: func(i *Interpreter, args []interface{}) (interface{}, *RuntimeError) {
+				re, err := regexArg(native, args, 0)
+				if err != nil {
+					return nil, err
+				}
+				return op.fn(native, re, args, 1)
+			},
+		}
+	}
+
+	return &LoxModule{Name: "regex", Members: members}
+}
+
+// regex.compile()
+func regexCompileNative(i *Interpreter, args []interface{}) (interface{}, *RuntimeError) {
+	re, err := regexArg("regex.compile", args, 0)
+	if err != nil {
+		return nil, err
+	}
+	return &LoxRegex{Regexp: re}, nil
+}
+
+func regexArg(native string, args []interface{}, idx int) (*regexp.Regexp, *RuntimeError) {
+	pattern, err := stringArg(native, args, idx)
+	if err != nil {
+		return nil, err
+	}
+
+	re, reErr := regexp.Compile(pattern)
+	if reErr != nil {
+		msg := fmt.Sprintf("Invalid regular expression: %s.", reErr)
+		return nil, &RuntimeError{Message: msg}
+	}
+	return re, nil
+}
+
+func regexMatch(native string, re *regexp.Regexp, args []interface{}, first int) (interface{}, *RuntimeError) {
+	str, err := stringArg(native, args, first)
+	if err != nil {
+		return nil, err
+	}
+	return re.MatchString(str), nil
+}
+
+// find returns nil when there's no match.
+func regexFind(native string, re *regexp.Regexp, args []interface{}, first int) (interface{}, *RuntimeError) {
+	str, err := stringArg(native, args, first)
+	if err != nil {
+		return nil, err
+	}
+
+	loc := re.FindStringIndex(str)
+	if loc == nil {
+		return nil, nil
+	}
+	return str[loc[0]:loc[1]], nil
+}
+
+func regexFindAll(native string, re *regexp.Regexp, args []interface{}, first int) (interface{}, *RuntimeError) {
+	str, err := stringArg(native, args, first)
+	if err != nil {
+		return nil, err
+	}
+
+	elements := []interface{}{}
+	for _, match := range re.FindAllString(str, -1) {
+		elements = append(elements, match)
+	}
+	return &LoxList{Elements: elements}, nil
+}
+
+// replace expands $1 or ${name} in the replacement to capture groups.
+func regexReplace(native string, re *regexp.Regexp, args []interface{}, first int) (interface{}, *RuntimeError) {
+	str, err := stringArg(native, args, first)
+	if err != nil {
+		return nil, err
+	}
+	replacement, err := stringArg(native, args, first+1)
+	if err != nil {
+		return nil, err
+	}
+	return re.ReplaceAllString(str, replacement), nil
+}
+
+func regexSplit(native string, re *regexp.Regexp, args []interface{}, first int) (interface{}, *RuntimeError) {
+	str, err := stringArg(native, args, first)
+	if err != nil {
+		return nil, err
+	}
+
+	elements := []interface{}{}
+	for _, part := range re.Split(str, -1) {
+		elements = append(elements, part)
+	}
+	return &LoxList{Elements: elements}, nil
+}
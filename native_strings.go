@@ -0,0 +1,363 @@
+package glox
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// String natives, in the strings module. Lengths and indexes count
+// characters, not bytes.
+var stringsModule = &LoxModule{
+	Name: "strings",
+	Members: map[string]interface{}{
+		"substr":     &NativeFunction{Name: "strings.substr", Signature: Arity{Min: 2, Max: 3}, Fn: substrNative},
+		"indexOf":    &NativeFunction{Name: "strings.indexOf", Signature: Arity{Min: 2, Max: 2}, Fn: indexOfNative},
+		"split":      &NativeFunction{Name: "strings.split", Signature: Arity{Min: 2, Max: 2}, Fn: splitNative},
+		"join":       &NativeFunction{Name: "strings.join", Signature: Arity{Min: 2, Max: 2}, Fn: joinNative},
+		"replace":    &NativeFunction{Name: "strings.replace", Signature: Arity{Min: 3, Max: 3}, Fn: replaceNative},
+		"upper":      &NativeFunction{Name: "strings.upper", Signature: Arity{Min: 1, Max: 1}, Fn: upperNative},
+		"lower":      &NativeFunction{Name: "strings.lower", Signature: Arity{Min: 1, Max: 1}, Fn: lowerNative},
+		"trim":       &NativeFunction{Name: "strings.trim", Signature: Arity{Min: 1, Max: 1}, Fn: trimNative},
+		"startsWith": &NativeFunction{Name: "strings.startsWith", Signature: Arity{Min: 2, Max: 2}, Fn: startsWithNative},
+		"endsWith":   &NativeFunction{Name: "strings.endsWith", Signature: Arity{Min: 2, Max: 2}, Fn: endsWithNative},
+		"repeat":     &NativeFunction{Name: "strings.repeat", Signature: Arity{Min: 2, Max: 2}, Fn: repeatNative},
+		"chars":      &NativeFunction{Name: "strings.chars", Signature: Arity{Min: 1, Max: 1}, Fn: charsNative},
+		"format":     &NativeFunction{Name: "strings.format", Signature: Arity{Min: 1, Max: 1, Variadic: true}, Fn: formatNative},
+	},
+}
+
+// len(), str() and num() stay global, as they take any value rather
+// than only strings.
+var conversionNatives = []*NativeFunction{
+	{Name: "len", Signature: Arity{Min: 1, Max: 1}, Fn: lenNative},
+	{Name: "str", Signature: Arity{Min: 1, Max: 1}, Fn: strNative},
+	{Name: "num", Signature: Arity{Min: 1, Max: 1}, Fn: numNative},
+}
+
+// len()
+func lenNative(i *Interpreter, args []interface{}) (interface{}, *RuntimeError) {
+	switch val := args[0].(type) {
+	case string:
+		return float64(utf8.RuneCountInString(val)), nil
+	case *LoxList:
+		return float64(val.len()), nil
+	case *LoxMap:
+		return float64(val.len()), nil
+	}
+
+	return nil, argumentError("len", 0, "a string, list or map")
+}
+
+// strings.substr()
+func substrNative(i *Interpreter, args []interface{}) (interface{}, *RuntimeError) {
+	str, err := stringArg("strings.substr", args, 0)
+	if err != nil {
+		return nil, err
+	}
+	start, err := intArg("strings.substr", args, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	runes := []rune(str)
+	end := len(runes)
+	if len(args) > 2 {
+		end, err = intArg("strings.substr", args, 2)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	if start < 0 || end > len(runes) || start > end {
+		msg := fmt.Sprintf("Substring range %d to %d out of bounds for length %d.", start, end, len(runes))
+		return nil, &RuntimeError{Message: msg}
+	}
+
+	return string(runes[start:end]), nil
+}
+
+// strings.indexOf()
+func indexOfNative(i *Interpreter, args []interface{}) (interface{}, *RuntimeError) {
+	str, err := stringArg("strings.indexOf", args, 0)
+	if err != nil {
+		return nil, err
+	}
+	sub, err := stringArg("strings.indexOf", args, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	idx := strings.Index(str, sub)
+	if idx == -1 {
+		return float64(-1), nil
+	}
+	return float64(utf8.RuneCountInString(str[:idx])), nil
+}
+
+// strings.split()
+func splitNative(i *Interpreter, args []interface{}) (interface{}, *RuntimeError) {
+	str, err := stringArg("strings.split", args, 0)
+	if err != nil {
+		return nil, err
+	}
+	sep, err := stringArg("strings.split", args, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	elements := []interface{}{}
+	for _, part := range strings.Split(str, sep) {
+		elements = append(elements, part)
+	}
+	return &LoxList{Elements: elements}, nil
+}
+
+// strings.join()
+func joinNative(i *Interpreter, args []interface{}) (interface{}, *RuntimeError) {
+	list, err := listArg("strings.join", args, 0)
+	if err != nil {
+		return nil, err
+	}
+	sep, err := stringArg("strings.join", args, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	strs := []string{}
+	for _, element := range list.elements() {
+		strs = append(strs, i.stringify(element))
+	}
+	return strings.Join(strs, sep), nil
+}
+
+// strings.replace()
+func replaceNative(i *Interpreter, args []interface{}) (interface{}, *RuntimeError) {
+	str, err := stringArg("strings.replace", args, 0)
+	if err != nil {
+		return nil, err
+	}
+	old, err := stringArg("strings.replace", args, 1)
+	if err != nil {
+		return nil, err
+	}
+	new, err := stringArg("strings.replace", args, 2)
+	if err != nil {
+		return nil, err
+	}
+
+	return strings.Replace(str, old, new, -1), nil
+}
+
+// strings.upper()
+func upperNative(i *Interpreter, args []interface{}) (interface{}, *RuntimeError) {
+	str, err := stringArg("strings.upper", args, 0)
+	if err != nil {
+		return nil, err
+	}
+	return strings.ToUpper(str), nil
+}
+
+// strings.lower()
+func lowerNative(i *Interpreter, args []interface{}) (interface{}, *RuntimeError) {
+	str, err := stringArg("strings.lower", args, 0)
+	if err != nil {
+		return nil, err
+	}
+	return strings.ToLower(str), nil
+}
+
+// strings.trim()
+func trimNative(i *Interpreter, args []interface{}) (interface{}, *RuntimeError) {
+	str, err := stringArg("strings.trim", args, 0)
+	if err != nil {
+		return nil, err
+	}
+	return strings.TrimSpace(str), nil
+}
+
+// strings.startsWith()
+func startsWithNative(i *Interpreter, args []interface{}) (interface{}, *RuntimeError) {
+	str, err := stringArg("strings.startsWith", args, 0)
+	if err != nil {
+		return nil, err
+	}
+	prefix, err := stringArg("strings.startsWith", args, 1)
+	if err != nil {
+		return nil, err
+	}
+	return strings.HasPrefix(str, prefix), nil
+}
+
+// strings.endsWith()
+func endsWithNative(i *Interpreter, args []interface{}) (interface{}, *RuntimeError) {
+	str, err := stringArg("strings.endsWith", args, 0)
+	if err != nil {
+		return nil, err
+	}
+	suffix, err := stringArg("strings.endsWith", args, 1)
+	if err != nil {
+		return nil, err
+	}
+	return strings.HasSuffix(str, suffix), nil
+}
+
+// strings.repeat()
+func repeatNative(i *Interpreter, args []interface{}) (interface{}, *RuntimeError) {
+	str, err := stringArg("strings.repeat", args, 0)
+	if err != nil {
+		return nil, err
+	}
+	count, err := intArg("strings.repeat", args, 1)
+	if err != nil {
+		return nil, err
+	}
+	if count < 0 {
+		return nil, &RuntimeError{Message: "Repeat count must not be negative."}
+	}
+	size := len(str) * count
+	if count > 0 && size/count != len(str) {
+		return nil, &RuntimeError{Message: "Repeat count is too large."}
+	}
+	if err := i.checkStringSize(nil, size); err != nil {
+		return nil, err
+	}
+	return strings.Repeat(str, count), nil
+}
+
+// strings.chars()
+func charsNative(i *Interpreter, args []interface{}) (interface{}, *RuntimeError) {
+	str, err := stringArg("strings.chars", args, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	elements := []interface{}{}
+	for _, char := range str {
+		elements = append(elements, string(char))
+	}
+	return &LoxList{Elements: elements}, nil
+}
+
+// strings.format()
+func formatNative(i *Interpreter, args []interface{}) (interface{}, *RuntimeError) {
+	format, err := stringArg("strings.format", args, 0)
+	if err != nil {
+		return nil, err
+	}
+	return formatString(i, format, args[1:])
+}
+
+// formatString handles printf-style verbs. Flags, width and precision
+// are passed through to Go's fmt.
+func formatString(i *Interpreter, format string, args []interface{}) (string, *RuntimeError) {
+	var out strings.Builder
+	argIdx := 0
+
+	for idx := 0; idx < len(format); idx++ {
+		if format[idx] != '%' {
+			out.WriteByte(format[idx])
+			continue
+		}
+
+		end := idx + 1
+		for end < len(format) && strings.IndexByte("+-# 0123456789.", format[end]) >= 0 {
+			end++
+		}
+		if end >= len(format) {
+			return "", &RuntimeError{Message: "Incomplete format verb at end of string."}
+		}
+		verb := format[end]
+		spec := format[idx : end+1]
+		idx = end
+
+		if verb == '%' {
+			out.WriteByte('%')
+			continue
+		}
+
+		if argIdx >= len(args) {
+			return "", &RuntimeError{Message: "Not enough arguments for format string."}
+		}
+		arg := args[argIdx]
+		argIdx++
+
+		// Widths and precisions pad out to at least their size
+		if err := i.checkStringSize(nil, out.Len()+formatPadding(spec)); err != nil {
+			return "", err
+		}
+
+		switch verb {
+		case 'd', 'x', 'X', 'o', 'b':
+			num, ok := arg.(float64)
+			if !ok || num != float64(int64(num)) {
+				msg := fmt.Sprintf("Format verb '%%%c' expects an integer.", verb)
+				return "", &RuntimeError{Message: msg}
+			}
+			fmt.Fprintf(&out, spec, int64(num))
+		case 'f', 'e', 'E', 'g', 'G':
+			num, ok := arg.(float64)
+			if !ok {
+				msg := fmt.Sprintf("Format verb '%%%c' expects a number.", verb)
+				return "", &RuntimeError{Message: msg}
+			}
+			fmt.Fprintf(&out, spec, num)
+		case 's', 'v', 'q':
+			if verb == 'v' {
+				spec = spec[:len(spec)-1] + "s"
+			}
+			fmt.Fprintf(&out, spec, i.stringify(arg))
+		default:
+			msg := fmt.Sprintf("Unknown format verb '%%%c'.", verb)
+			return "", &RuntimeError{Message: msg}
+		}
+	}
+
+	if argIdx < len(args) {
+		return "", &RuntimeError{Message: "Too many arguments for format string."}
+	}
+
+	return out.String(), nil
+}
+
+// formatPadding returns the largest width or precision in a format
+// verb's spec.
+func formatPadding(spec string) int {
+	largest := 0
+	isSeparator := func(char rune) bool { return char < '0' || char > '9' }
+	for _, digits := range strings.FieldsFunc(spec[1:len(spec)-1], isSeparator) {
+		size, err := strconv.Atoi(digits)
+		if err != nil {
+			// Too many digits for an int
+			return int(^uint(0) >> 1)
+		}
+		if size > largest {
+			largest = size
+		}
+	}
+	return largest
+}
+
+// str()
+func strNative(i *Interpreter, args []interface{}) (interface{}, *RuntimeError) {
+	return i.stringify(args[0]), nil
+}
+
+// num()
+func numNative(i *Interpreter, args []interface{}) (interface{}, *RuntimeError) {
+	if num, ok := args[0].(float64); ok {
+		return num, nil
+	}
+
+	str, err := stringArg("num", args, 0)
+	if err != nil {
+		return nil, err
+	}
+	num, parseErr := strconv.ParseFloat(strings.TrimSpace(str), 64)
+	if parseErr != nil {
+		msg := fmt.Sprintf("Cannot convert '%s' to a number.", str)
+		return nil, &RuntimeError{Message: msg}
+	}
+	return num, nil
+}
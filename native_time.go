@@ -0,0 +1,268 @@
+package glox
+
+import (
+	"fmt"
+	"time"
+
+	// Zone conversions shouldn't depend on the host's zoneinfo
+	_ "time/tzdata"
+)
+
+// Durations are numbers of milliseconds, like clock().
+
+// LoxTime is a point in time with a zone, from time.now() or
+// time.parse().
+type LoxTime struct {
+	Time time.Time
+}
+
+func (t *LoxTime) String() string {
+	return t.Time.Format(time.RFC3339Nano)
+}
+
+func (t *LoxTime) get(name *Token) (interface{}, *RuntimeError) {
+	if method, ok := t.methods()[name.Lexeme]; ok {
+		return method, nil
+	}
+
+	return nil, &RuntimeError{
+		Token:   name,
+		Message: fmt.Sprintf("Undefined property '%s'.", name.Lexeme),
+	}
+}
+
+func (t *LoxTime) set(name *Token, value interface{}) *RuntimeError {
+	return readOnlyError(name, t)
+}
+
+func (t *LoxTime) methods() map[string]*NativeFunction {
+	component := func(name string, fn func(time.Time) int) *NativeFunction {
+		return &NativeFunction{
+			Name:      name,
+			Signature: Arity{Min: 0, Max: 0},
+			Fn: func(i *Interpreter, args []interface{}) (interface{}, *RuntimeError) {
+				return float64(fn(t.Time)), nil
+			},
+		}
+	}
+
+	return map[string]*NativeFunction{
+		"year":   component("year", time.Time.Year),
+		"month":  component("month", func(tm time.Time) int { return int(tm.Month()) }),
+		"day":    component("day", time.Time.Day),
+		"hour":   component("hour", time.Time.Hour),
+		"minute": component("minute", time.Time.Minute),
+		"second": component("second", time.Time.Second),
+		"format": {
+			Name:      "format",
+			Signature: Arity{Min: 1, Max: 1},
+			Fn: func(i *Interpreter, args []interface{}) (interface{}, *RuntimeError) {
+				layout, err := stringArg("format", args, 0)
+				if err != nil {
+					return nil, err
+				}
+				return t.Time.Format(layout), nil
+			},
+		},
+		"in": {
+			Name:      "in",
+			Signature: Arity{Min: 1, Max: 1},
+			Fn: func(i *Interpreter, args []interface{}) (interface{}, *RuntimeError) {
+				loc, err := locationArg("in", args, 0)
+				if err != nil {
+					return nil, err
+				}
+				return &LoxTime{Time: t.Time.In(loc)}, nil
+			},
+		},
+		"zone": {
+			Name:      "zone",
+			Signature: Arity{Min: 0, Max: 0},
+			Fn: func(i *Interpreter, args []interface{}) (interface{}, *RuntimeError) {
+				return t.Time.Location().String(), nil
+			},
+		},
+		"weekday": {
+			Name:      "weekday",
+			Signature: Arity{Min: 0, Max: 0},
+			Fn: func(i *Interpreter, args []interface{}) (interface{}, *RuntimeError) {
+				return t.Time.Weekday().String(), nil
+			},
+		},
+		"unix": {
+			Name:      "unix",
+			Signature: Arity{Min: 0, Max: 0},
+			Fn: func(i *Interpreter, args []interface{}) (interface{}, *RuntimeError) {
+				return float64(t.Time.UnixNano()) / float64(time.Millisecond), nil
+			},
+		},
+		"add": {
+			Name:      "add",
+			Signature: Arity{Min: 1, Max: 1},
+			Fn: func(i *Interpreter, args []interface{}) (interface{}, *RuntimeError) {
+				ms, err := numberArg("add", args, 0)
+				if err != nil {
+					return nil, err
+				}
+				return &LoxTime{Time: t.Time.Add(millisecondsToDuration(ms))}, nil
+			},
+		},
+		"sub": {
+			Name:      "sub",
+			Signature: Arity{Min: 1, Max: 1},
+			Fn: func(i *Interpreter, args []interface{}) (interface{}, *RuntimeError) {
+				other, ok := args[0].(*LoxTime)
+				if !ok {
+					return nil, argumentError("sub", 0, "a time")
+				}
+				return durationToMilliseconds(t.Time.Sub(other.Time)), nil
+			},
+		},
+	}
+}
+
+var timeModule = &LoxModule{
+	Name: "time",
+	Members: map[string]interface{}{
+		"now":         &NativeFunction{Name: "time.now", Signature: Arity{Min: 0, Max: 1}, Fn: timeNowNative},
+		"parse":       &NativeFunction{Name: "time.parse", Signature: Arity{Min: 2, Max: 3}, Fn: timeParseNative},
+		"fromUnix":    &NativeFunction{Name: "time.fromUnix", Signature: Arity{Min: 1, Max: 2}, Fn: timeFromUnixNative},
+		"duration":    &NativeFunction{Name: "time.duration", Signature: Arity{Min: 1, Max: 1}, Fn: timeDurationNative},
+		"sleep":       &NativeFunction{Name: "time.sleep", Signature: Arity{Min: 1, Max: 1}, Fn: timeSleepNative},
+		"perfCounter": &NativeFunction{Name: "time.perfCounter", Signature: Arity{Min: 0, Max: 0}, Fn: timePerfCounterNative},
+
+		// Layouts use Go's reference time, Mon Jan 2 15:04:05 MST 2006
+		"RFC3339":  time.RFC3339,
+		"RFC1123":  time.RFC1123,
+		"DateTime": "2006-01-02 15:04:05",
+		"Date":     "2006-01-02",
+		"Time":     "15:04:05",
+	},
+}
+
+// perfCounter() measures from here. Go's clock readings are monotonic
+// so differences aren't affected by wall clock changes.
+var perfCounterStart = time.Now()
+
+// time.now() takes an optional zone name, it's local time otherwise.
+func timeNowNative(i *Interpreter, args []interface{}) (interface{}, *RuntimeError) {
+	now := time.Now()
+	if len(args) > 0 {
+		loc, err := locationArg("time.now", args, 0)
+		if err != nil {
+			return nil, err
+		}
+		now = now.In(loc)
+	}
+	return &LoxTime{Time: now}, nil
+}
+
+// time.parse() reads times without a zone in the optional zone name,
+// or UTC.
+func timeParseNative(i *Interpreter, args []interface{}) (interface{}, *RuntimeError) {
+	value, err := stringArg("time.parse", args, 0)
+	if err != nil {
+		return nil, err
+	}
+	layout, err := stringArg("time.parse", args, 1)
+	if err != nil {
+		return nil, err
+	}
+
+	loc := time.UTC
+	if len(args) > 2 {
+		loc, err = locationArg("time.parse", args, 2)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	parsed, parseErr := time.ParseInLocation(layout, value, loc)
+	if parseErr != nil {
+		msg := fmt.Sprintf("Cannot parse time: %s.", parseErr)
+		return nil, &RuntimeError{Message: msg}
+	}
+	return &LoxTime{Time: parsed}, nil
+}
+
+// time.fromUnix()
+func timeFromUnixNative(i *Interpreter, args []interface{}) (interface{}, *RuntimeError) {
+	ms, err := numberArg("time.fromUnix", args, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	loc := time.UTC
+	if len(args) > 1 {
+		loc, err = locationArg("time.fromUnix", args, 1)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return &LoxTime{Time: time.Unix(0, 0).Add(millisecondsToDuration(ms)).In(loc)}, nil
+}
+
+// time.duration() converts strings like "1h30m" to milliseconds.
+func timeDurationNative(i *Interpreter, args []interface{}) (interface{}, *RuntimeError) {
+	str, err := stringArg("time.duration", args, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	duration, parseErr := time.ParseDuration(str)
+	if parseErr != nil {
+		msg := fmt.Sprintf("Cannot parse duration: %s.", parseErr)
+		return nil, &RuntimeError{Message: msg}
+	}
+	return durationToMilliseconds(duration), nil
+}
+
+// time.sleep()
+func timeSleepNative(i *Interpreter, args []interface{}) (interface{}, *RuntimeError) {
+	ms, err := numberArg("time.sleep", args, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	// Wake up early if the script's context is done
+	if i.budget == nil {
+		time.Sleep(millisecondsToDuration(ms))
+		return nil, nil
+	}
+	timer := time.NewTimer(millisecondsToDuration(ms))
+	defer timer.Stop()
+	select {
+	case <-timer.C:
+		return nil, nil
+	case <-i.budget.ctx.Done():
+		return nil, i.checkContext()
+	}
+}
+
+// time.perfCounter() returns seconds, for timing benchmarks.
+func timePerfCounterNative(i *Interpreter, args []interface{}) (interface{}, *RuntimeError) {
+	return time.Since(perfCounterStart).Seconds(), nil
+}
+
+func locationArg(native string, args []interface{}, idx int) (*time.Location, *RuntimeError) {
+	name, err := stringArg(native, args, idx)
+	if err != nil {
+		return nil, err
+	}
+
+	loc, locErr := time.LoadLocation(name)
+	if locErr != nil {
+		msg := fmt.Sprintf("Unknown time zone '%s'.", name)
+		return nil, &RuntimeError{Message: msg}
+	}
+	return loc, nil
+}
+
+func millisecondsToDuration(ms float64) time.Duration {
+	return time.Duration(ms * float64(time.Millisecond))
+}
+
+func durationToMilliseconds(duration time.Duration) float64 {
+	return float64(duration) / float64(time.Millisecond)
+}
@@ -1,4 +1,4 @@
-package main
+package glox
 
 import (
 	"errors"
@@ -8,6 +8,8 @@ import (
 type Parser struct {
 	Tokens  []*Token
 	Current int
+	// Reports the errors found
+	Lox *Lox
 }
 
 func (p *Parser) parse() []Stmt {
@@ -25,10 +27,21 @@ func (p *Parser) declaration() Stmt {
 	var statement Stmt
 	if p.match(Class) {
 		statement, err = p.classDeclaration()
+	} else if p.match(Async) {
+		_, err = p.consume(Fun, "Expect 'fun' after 'async'.")
+		if err == nil {
+			statement, err = p.async("function")
+		}
 	} else if p.match(Fun) {
-		statement, err = p.function("function")
+		if p.match(Star) {
+			statement, err = p.generator("function")
+		} else {
+			statement, err = p.function("function")
+		}
 	} else if p.match(Var) {
 		statement, err = p.varDeclaration()
+	} else if p.match(Const) {
+		statement, err = p.constDeclaration()
 	} else {
 		statement, err = p.statement()
 	}
@@ -40,6 +53,10 @@ func (p *Parser) declaration() Stmt {
 }
 
 func (p *Parser) varDeclaration() (Stmt, error) {
+	if p.check(LeftBracket) || p.check(LeftBrace) {
+		return p.varPattern()
+	}
+
 	var initializer Expr
 	var err error
 
@@ -66,6 +83,61 @@ func (p *Parser) varDeclaration() (Stmt, error) {
 	}, nil
 }
 
+func (p *Parser) constDeclaration() (Stmt, error) {
+	name, err := p.consume(Identifier, "Expect constant name.")
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = p.consume(Equal, "Expect '=' after constant name.")
+	if err != nil {
+		return nil, err
+	}
+	initializer, err := p.expression()
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = p.consume(Semicolon, "Expect ';' after constant declaration.")
+	if err != nil {
+		return nil, err
+	}
+
+	return &VarStmt{
+		Name:        name,
+		Initializer: initializer,
+		IsConst:     true,
+	}, nil
+}
+
+func (p *Parser) varPattern() (Stmt, error) {
+	keyword := p.previous()
+	pattern, err := p.singlePattern()
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = p.consume(Equal, "Expect '=' after destructuring pattern.")
+	if err != nil {
+		return nil, err
+	}
+	initializer, err := p.expression()
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = p.consume(Semicolon, "Expect ';' after variable declaration.")
+	if err != nil {
+		return nil, err
+	}
+
+	return &VarPatternStmt{
+		Keyword:     keyword,
+		Pattern:     pattern,
+		Initializer: initializer,
+	}, nil
+}
+
 func (p *Parser) statement() (Stmt, error) {
 	if p.match(For) {
 		return p.forStatement()
@@ -76,6 +148,18 @@ func (p *Parser) statement() (Stmt, error) {
 	if p.match(Return) {
 		return p.returnStatement()
 	}
+	if p.match(Yield) {
+		return p.yieldStatement()
+	}
+	if p.match(Select) {
+		return p.selectStatement()
+	}
+	if p.match(Switch) {
+		return p.switchStatement()
+	}
+	if p.match(Break) {
+		return p.breakStatement()
+	}
 	if p.match(While) {
 		return p.whileStatement()
 	}
@@ -92,11 +176,17 @@ func (p *Parser) statement() (Stmt, error) {
 }
 
 func (p *Parser) forStatement() (Stmt, error) {
+	keyword := p.previous()
 	_, err := p.consume(LeftParen, "Expect '(' after 'for'.")
 	if err != nil {
 		return nil, err
 	}
 
+	// for (var x in collection)
+	if p.check(Var) && p.checkNext(Identifier) && p.Tokens[p.Current+2].Type == In {
+		return p.forInStatement(keyword)
+	}
+
 	// Initializer
 	var initializer Stmt
 	if p.match(Semicolon) {
@@ -164,6 +254,7 @@ func (p *Parser) forStatement() (Stmt, error) {
 		}
 	}
 	body = &WhileStmt{
+		Keyword:   keyword,
 		Condition: condition,
 		Body:      body,
 	}
@@ -231,6 +322,23 @@ func (p *Parser) returnStatement() (Stmt, error) {
 		}
 	}
 
+	// Several values are returned as a list, for the caller to
+	// destructure
+	if p.check(Comma) {
+		values := []Expr{value}
+		for p.match(Comma) {
+			value, err = p.expression()
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, value)
+		}
+		value = &ListExpr{
+			Bracket:  keyword,
+			Elements: values,
+		}
+	}
+
 	_, err = p.consume(Semicolon, "Expect ';' after return value.")
 	if err != nil {
 		return nil, err
@@ -242,7 +350,230 @@ func (p *Parser) returnStatement() (Stmt, error) {
 	}, nil
 }
 
+func (p *Parser) yieldStatement() (Stmt, error) {
+	keyword := p.previous()
+
+	var value Expr
+	var err error
+
+	if !p.check(Semicolon) {
+		value, err = p.expression()
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	_, err = p.consume(Semicolon, "Expect ';' after yield value.")
+	if err != nil {
+		return nil, err
+	}
+
+	return &YieldStmt{
+		Keyword: keyword,
+		Value:   value,
+	}, nil
+}
+
+func (p *Parser) forInStatement(keyword *Token) (Stmt, error) {
+	p.advance()
+	name := p.advance()
+	p.advance()
+
+	iterable, err := p.expression()
+	if err != nil {
+		return nil, err
+	}
+	_, err = p.consume(RightParen, "Expect ')' after for clauses.")
+	if err != nil {
+		return nil, err
+	}
+
+	// Body
+	body, err := p.statement()
+	if err != nil {
+		return nil, err
+	}
+
+	return &ForInStmt{
+		Keyword:  keyword,
+		Name:     name,
+		Iterable: iterable,
+		Body:     body,
+	}, nil
+}
+
+func (p *Parser) selectStatement() (Stmt, error) {
+	keyword := p.previous()
+	_, err := p.consume(LeftBrace, "Expect '{' after 'select'.")
+	if err != nil {
+		return nil, err
+	}
+
+	stmt := &SelectStmt{Keyword: keyword}
+	for !p.check(RightBrace) && !p.isAtEnd() {
+		if p.match(Default) {
+			if stmt.Default != nil {
+				return nil, p.error(p.previous(), "Cannot have more than one default case.")
+			}
+			_, err = p.consume(Colon, "Expect ':' after 'default'.")
+			if err != nil {
+				return nil, err
+			}
+			stmt.Default = p.caseBody()
+			continue
+		}
+
+		_, err = p.consume(Case, "Expect 'case' or 'default' in select.")
+		if err != nil {
+			return nil, err
+		}
+		selectCase, err := p.selectCase()
+		if err != nil {
+			return nil, err
+		}
+		stmt.Cases = append(stmt.Cases, selectCase)
+	}
+
+	_, err = p.consume(RightBrace, "Expect '}' after select cases.")
+	if err != nil {
+		return nil, err
+	}
+
+	return stmt, nil
+}
+
+// selectCase parses `case ch.send(value):` or `case var x = ch.recv():`
+// after the `case`.
+func (p *Parser) selectCase() (*SelectCase, error) {
+	selectCase := &SelectCase{Keyword: p.previous()}
+
+	var err error
+	if p.match(Var) {
+		selectCase.Name, err = p.consume(Identifier, "Expect variable name.")
+		if err != nil {
+			return nil, err
+		}
+		_, err = p.consume(Equal, "Expect '=' after variable name.")
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	expr, err := p.call()
+	if err != nil {
+		return nil, err
+	}
+
+	// Only channel operations can be cases
+	call, isCall := expr.(*CallExpr)
+	var method *GetExpr
+	if isCall {
+		method, _ = call.Callee.(*GetExpr)
+	}
+	switch {
+	case method != nil && method.Name.Lexeme == "recv" && len(call.Arguments) == 0:
+	case method != nil && method.Name.Lexeme == "send" && len(call.Arguments) == 1 && selectCase.Name == nil:
+		selectCase.IsSend = true
+		selectCase.Value = call.Arguments[0]
+	default:
+		return nil, p.error(selectCase.Keyword, "Select cases must be a channel's send(value) or recv().")
+	}
+	selectCase.Channel = method.Object
+
+	_, err = p.consume(Colon, "Expect ':' after select case.")
+	if err != nil {
+		return nil, err
+	}
+	selectCase.Body = p.caseBody()
+
+	return selectCase, nil
+}
+
+func (p *Parser) switchStatement() (Stmt, error) {
+	keyword := p.previous()
+	_, err := p.consume(LeftParen, "Expect '(' after 'switch'.")
+	if err != nil {
+		return nil, err
+	}
+	value, err := p.expression()
+	if err != nil {
+		return nil, err
+	}
+	_, err = p.consume(RightParen, "Expect ')' after switch value.")
+	if err != nil {
+		return nil, err
+	}
+	_, err = p.consume(LeftBrace, "Expect '{' after switch value.")
+	if err != nil {
+		return nil, err
+	}
+
+	stmt := &SwitchStmt{Keyword: keyword, Value: value}
+	for !p.check(RightBrace) && !p.isAtEnd() {
+		if p.match(Default) {
+			if stmt.Default != nil {
+				return nil, p.error(p.previous(), "Cannot have more than one default case.")
+			}
+			_, err = p.consume(Colon, "Expect ':' after 'default'.")
+			if err != nil {
+				return nil, err
+			}
+			stmt.Default = p.caseBody()
+			continue
+		}
+
+		_, err = p.consume(Case, "Expect 'case' or 'default' in switch.")
+		if err != nil {
+			return nil, err
+		}
+		switchCase := &SwitchCase{Keyword: p.previous()}
+		for {
+			value, err := p.expression()
+			if err != nil {
+				return nil, err
+			}
+			switchCase.Values = append(switchCase.Values, value)
+			if !p.match(Comma) {
+				break
+			}
+		}
+		_, err = p.consume(Colon, "Expect ':' after case values.")
+		if err != nil {
+			return nil, err
+		}
+		switchCase.Body = p.caseBody()
+		stmt.Cases = append(stmt.Cases, switchCase)
+	}
+
+	_, err = p.consume(RightBrace, "Expect '}' after switch cases.")
+	if err != nil {
+		return nil, err
+	}
+
+	return stmt, nil
+}
+
+func (p *Parser) breakStatement() (Stmt, error) {
+	keyword := p.previous()
+	_, err := p.consume(Semicolon, "Expect ';' after 'break'.")
+	if err != nil {
+		return nil, err
+	}
+	return &BreakStmt{Keyword: keyword}, nil
+}
+
+// caseBody parses the statements up to the next case.
+func (p *Parser) caseBody() []Stmt {
+	statements := []Stmt{}
+	for !p.check(Case) && !p.check(Default) && !p.check(RightBrace) && !p.isAtEnd() {
+		statements = append(statements, p.declaration())
+	}
+	return statements
+}
+
 func (p *Parser) whileStatement() (Stmt, error) {
+	keyword := p.previous()
+
 	// Condition
 	_, err := p.consume(LeftParen, "Expect '(' after 'while'.")
 	if err != nil {
@@ -264,6 +595,7 @@ func (p *Parser) whileStatement() (Stmt, error) {
 	}
 
 	return &WhileStmt{
+		Keyword:   keyword,
 		Condition: condition,
 		Body:      body,
 	}, nil
@@ -323,7 +655,14 @@ func (p *Parser) classDeclaration() (*ClassStmt, error) {
 	methods := []*FunctionStmt{}
 
 	for !p.check(RightBrace) && !p.isAtEnd() {
-		method, err := p.function("method")
+		var method *FunctionStmt
+		if p.match(Async) {
+			method, err = p.async("method")
+		} else if p.match(Star) {
+			method, err = p.generator("method")
+		} else {
+			method, err = p.function("method")
+		}
 		if err != nil {
 			return nil, err
 		}
@@ -342,6 +681,27 @@ func (p *Parser) classDeclaration() (*ClassStmt, error) {
 	}, nil
 }
 
+func (p *Parser) generator(kind string) (*FunctionStmt, error) {
+	function, err := p.function(kind)
+	if err != nil {
+		return nil, err
+	}
+	function.IsGenerator = true
+	return function, nil
+}
+
+func (p *Parser) async(kind string) (*FunctionStmt, error) {
+	if p.check(Star) {
+		return nil, p.error(p.peek(), "Generators cannot be async.")
+	}
+	function, err := p.function(kind)
+	if err != nil {
+		return nil, err
+	}
+	function.IsAsync = true
+	return function, nil
+}
+
 func (p *Parser) function(kind string) (*FunctionStmt, error) {
 	// Func name
 	name, err := p.consume(Identifier, fmt.Sprintf("Expect %s name.", kind))
@@ -357,17 +717,46 @@ func (p *Parser) function(kind string) (*FunctionStmt, error) {
 
 	// Params
 	parameters := []*Token{}
+	defaults := []Expr{}
+	hasDefaults := false
+	var rest *Token
 	if !p.check(RightParen) {
 		for {
 			if len(parameters) >= 8 {
 				_ = p.error(p.peek(), "Cannot have more than 8 parameters.")
 			}
 
+			// Rest param collects remaining args and must be last
+			if p.match(DotDotDot) {
+				rest, err = p.consume(Identifier, "Expect parameter name after '...'.")
+				if err != nil {
+					return nil, err
+				}
+				if p.check(Comma) {
+					return nil, p.error(p.peek(), "Rest parameter must be last.")
+				}
+				break
+			}
+
 			newParam, err := p.consume(Identifier, "Expect parameter name.")
 			if err != nil {
 				return nil, err
 			}
+
+			// Default value
+			var def Expr
+			if p.match(Equal) {
+				def, err = p.expression()
+				if err != nil {
+					return nil, err
+				}
+				hasDefaults = true
+			} else if hasDefaults {
+				_ = p.error(newParam, "Cannot have a required parameter after an optional one.")
+			}
+
 			parameters = append(parameters, newParam)
+			defaults = append(defaults, def)
 
 			if !p.match(Comma) {
 				break
@@ -394,9 +783,11 @@ func (p *Parser) function(kind string) (*FunctionStmt, error) {
 	}
 
 	return &FunctionStmt{
-		Name:   name,
-		Params: parameters,
-		Body:   body,
+		Name:     name,
+		Params:   parameters,
+		Defaults: defaults,
+		Rest:     rest,
+		Body:     body,
 	}, nil
 }
 
@@ -405,45 +796,151 @@ func (p *Parser) expression() (Expr, error) {
 }
 
 func (p *Parser) assignment() (Expr, error) {
-	expr, err := p.or()
+	expr, err := p.conditional()
 	if err != nil {
 		return nil, err
 	}
 
-	if p.match(Equal) {
+	if p.match(Equal, PlusEqual, MinusEqual, StarEqual, SlashEqual, PercentEqual) {
 		equals := p.previous()
 		value, err := p.assignment()
 		if err != nil {
 			return nil, err
 		}
 
-		if varExpr, ok := expr.(*VarExpr); ok {
-			return &AssignExpr{
-				Name:  varExpr.Name,
-				Value: value,
-			}, nil
-		} else if getExpr, ok := expr.(*GetExpr); ok {
-			return &SetExpr{
-				Object: getExpr.Object,
-				Name:   getExpr.Name,
-				Value:  value,
-			}, nil
+		var operator *Token
+		if equals.Type != Equal {
+			operator = compoundOperator(equals)
 		}
+		return p.assignTo(expr, equals, operator, value)
+	}
+
+	return expr, nil
+}
+
+// assignTo makes an assignment of value to target, applying operator
+// to target's current value first if there is one.
+func (p *Parser) assignTo(target Expr, equals *Token, operator *Token, value Expr) (Expr, error) {
+	// A ?. could skip the target, leaving nothing to assign to
+	if inOptionalChain(target) {
+		return nil, p.error(equals, "Invalid assignment target.")
+	}
+
+	if varExpr, ok := target.(*VarExpr); ok {
+		return &AssignExpr{
+			Name:     varExpr.Name,
+			Operator: operator,
+			Value:    value,
+		}, nil
+	} else if getExpr, ok := target.(*GetExpr); ok {
+		return &SetExpr{
+			Object:   getExpr.Object,
+			Name:     getExpr.Name,
+			Operator: operator,
+			Value:    value,
+		}, nil
+	} else if indexExpr, ok := target.(*IndexExpr); ok {
+		return &IndexSetExpr{
+			Object:   indexExpr.Object,
+			Bracket:  indexExpr.Bracket,
+			Index:    indexExpr.Index,
+			Operator: operator,
+			Value:    value,
+		}, nil
+	} else if listExpr, ok := target.(*ListExpr); ok && operator == nil && isDestructureTarget(listExpr) {
+		return &DestructureExpr{
+			Bracket: listExpr.Bracket,
+			Targets: listExpr.Elements,
+			Value:   value,
+		}, nil
+	}
+
+	return nil, p.error(equals, "Invalid assignment target.")
+}
+
+// compoundOperator returns the binary operator a compound assignment
+// applies, keeping its lexeme for errors.
+func compoundOperator(token *Token) *Token {
+	var tokenType TokenType
+	switch token.Type {
+	case PlusEqual, PlusPlus:
+		tokenType = Plus
+	case MinusEqual, MinusMinus:
+		tokenType = Minus
+	case StarEqual:
+		tokenType = Star
+	case SlashEqual:
+		tokenType = Slash
+	case PercentEqual:
+		tokenType = Percent
+	}
+
+	return &Token{
+		Type:   tokenType,
+		Lexeme: token.Lexeme,
+		Line:   token.Line,
+	}
+}
 
-		err = p.error(equals, "Invalid assignment target.")
+func (p *Parser) conditional() (Expr, error) {
+	expr, err := p.or()
+	if err != nil {
 		return nil, err
 	}
 
+	if p.match(Question) {
+		question := p.previous()
+		thenBranch, err := p.expression()
+		if err != nil {
+			return nil, err
+		}
+		_, err = p.consume(Colon, "Expect ':' after then branch of conditional expression.")
+		if err != nil {
+			return nil, err
+		}
+		elseBranch, err := p.conditional()
+		if err != nil {
+			return nil, err
+		}
+
+		expr = &ConditionalExpr{
+			Condition: expr,
+			Question:  question,
+			Then:      thenBranch,
+			Else:      elseBranch,
+		}
+	}
+
 	return expr, nil
 }
 
+// isDestructureTarget reports whether expr can be assigned to, so a list
+// of them can be destructured into.
+func isDestructureTarget(expr Expr) bool {
+	switch target := expr.(type) {
+	case *VarExpr:
+		return true
+	case *GetExpr, *IndexExpr:
+		return !inOptionalChain(target)
+	case *ListExpr:
+		for _, element := range target.Elements {
+			if !isDestructureTarget(element) {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
 func (p *Parser) or() (Expr, error) {
 	expr, err := p.and()
 	if err != nil {
 		return nil, err
 	}
 
-	for p.match(Or) {
+	// a ?? b is a unless a is nil, short circuiting like or
+	for p.match(Or, QuestionQuestion) {
 		operator := p.previous()
 		right, err := p.and()
 		if err != nil {
@@ -509,7 +1006,7 @@ func (p *Parser) comparison() (Expr, error) {
 		return nil, err
 	}
 
-	for p.match(Greater, GreaterEqual, Less, LessEqual) {
+	for p.match(Greater, GreaterEqual, Less, LessEqual, Is) {
 		operator := p.previous()
 		right, err := p.addition()
 		if err != nil {
@@ -531,12 +1028,16 @@ func (p *Parser) addition() (Expr, error) {
 		return nil, err
 	}
 
-	for p.match(Minus, Plus) {
+	for p.match(Minus, Plus, MinusMinus) {
 		operator := p.previous()
 		right, err := p.multiplication()
 		if err != nil {
 			return nil, err
 		}
+		// a--b that isn't a decrement is a minus -b
+		if operator.Type == MinusMinus {
+			operator, right = splitMinusMinus(operator, right)
+		}
 		expr = &BinaryExpr{
 			Left:     expr,
 			Operator: operator,
@@ -553,7 +1054,7 @@ func (p *Parser) multiplication() (Expr, error) {
 		return nil, err
 	}
 
-	for p.match(Slash, Star) {
+	for p.match(Slash, Star, Percent) {
 		operator := p.previous()
 		right, err := p.unary()
 		if err != nil {
@@ -570,19 +1071,130 @@ func (p *Parser) multiplication() (Expr, error) {
 }
 
 func (p *Parser) unary() (Expr, error) {
-	if p.match(Bang, Minus) {
+	if p.match(Spawn) {
+		keyword := p.previous()
+		expr, err := p.call()
+		if err != nil {
+			return nil, err
+		}
+		call, ok := expr.(*CallExpr)
+		if !ok {
+			return nil, p.error(keyword, "Expect a call after 'spawn'.")
+		}
+		return &SpawnExpr{
+			Keyword: keyword,
+			Call:    call,
+		}, nil
+	}
+
+	if p.match(Await) {
+		keyword := p.previous()
+		value, err := p.unary()
+		if err != nil {
+			return nil, err
+		}
+		return &AwaitExpr{
+			Keyword: keyword,
+			Value:   value,
+		}, nil
+	}
+
+	if p.match(Bang, Minus, MinusMinus) {
 		operator := p.previous()
 		right, err := p.unary()
 		if err != nil {
 			return nil, err
 		}
+		if operator.Type == MinusMinus {
+			operator, right = splitMinusMinus(operator, right)
+		}
 		return &UnaryExpr{
 			Operator: operator,
 			Right:    right,
 		}, nil
 	}
 
-	return p.call()
+	return p.postfix()
+}
+
+// postfix parses x++ and x--, which are short for x += 1 and x -= 1
+// but evaluate to x's value before. Followed by an operand, -- is two
+// minuses instead, as in a--b.
+func (p *Parser) postfix() (Expr, error) {
+	expr, err := p.call()
+	if err != nil {
+		return nil, err
+	}
+
+	if (!p.check(PlusPlus) && !p.check(MinusMinus)) || !isAssignable(expr) || p.startsOperand(p.Current+1) {
+		return expr, nil
+	}
+
+	increment := p.advance()
+	one := &LiteralExpr{Value: 1.0}
+	assignment, err := p.assignTo(expr, increment, compoundOperator(increment), one)
+	if err != nil {
+		return nil, err
+	}
+	switch assignment := assignment.(type) {
+	case *AssignExpr:
+		assignment.Postfix = true
+	case *SetExpr:
+		assignment.Postfix = true
+	case *IndexSetExpr:
+		assignment.Postfix = true
+	}
+	return assignment, nil
+}
+
+func isAssignable(expr Expr) bool {
+	switch expr.(type) {
+	case *VarExpr, *GetExpr, *IndexExpr:
+		return true
+	}
+	return false
+}
+
+// inOptionalChain reports whether expr is a property, call or index
+// after a ?., which a nil before the ?. skips.
+func inOptionalChain(expr Expr) bool {
+	for {
+		switch link := expr.(type) {
+		case *GetExpr:
+			if link.Optional {
+				return true
+			}
+			expr = link.Object
+		case *CallExpr:
+			expr = link.Callee
+		case *IndexExpr:
+			expr = link.Object
+		default:
+			return false
+		}
+	}
+}
+
+// startsOperand reports whether the token at idx can start an operand
+// that makes a -- before it two minuses. Prefix operators don't count,
+// so x-- - 1 and x++ - 1 are still increments.
+func (p *Parser) startsOperand(idx int) bool {
+	if idx >= len(p.Tokens) {
+		return false
+	}
+	switch p.Tokens[idx].Type {
+	case Number, String, Identifier, True, False, Nil, This, Super,
+		LeftParen, LeftBracket, LeftBrace, Match, Await, Spawn:
+		return true
+	}
+	return false
+}
+
+// splitMinusMinus turns a -- token that isn't a decrement back into
+// two minuses: the operator, and the negation of right.
+func splitMinusMinus(token *Token, right Expr) (*Token, Expr) {
+	minus := &Token{Type: Minus, Lexeme: "-", Line: token.Line}
+	return minus, &UnaryExpr{Operator: minus, Right: right}
 }
 
 func (p *Parser) call() (Expr, error) {
@@ -597,14 +1209,30 @@ func (p *Parser) call() (Expr, error) {
 			if err != nil {
 				return nil, err
 			}
-		} else if p.match(Dot) {
-			name, err := p.consume(Identifier, "Expect property name after '.'.")
+		} else if p.match(Dot, QuestionDot) {
+			optional := p.previous().Type == QuestionDot
+			name, err := p.propertyName()
 			if err != nil {
 				return nil, err
 			}
 			expr = &GetExpr{
-				Object: expr,
-				Name:   name,
+				Object:   expr,
+				Name:     name,
+				Optional: optional,
+			}
+		} else if p.match(LeftBracket) {
+			index, err := p.expression()
+			if err != nil {
+				return nil, err
+			}
+			bracket, err := p.consume(RightBracket, "Expect ']' after index.")
+			if err != nil {
+				return nil, err
+			}
+			expr = &IndexExpr{
+				Object:  expr,
+				Bracket: bracket,
+				Index:   index,
 			}
 		} else {
 			break
@@ -616,16 +1244,30 @@ func (p *Parser) call() (Expr, error) {
 
 func (p *Parser) finishCall(callee Expr) (Expr, error) {
 	arguments := []Expr{}
+	names := []*Token{}
+	hasNames := false
 	if !p.check(RightParen) {
 		for {
 			if len(arguments) > 8 {
 				_ = p.error(p.peek(), "Cannot have more than 8 arguments.")
 			}
+
+			// Named argument
+			var name *Token
+			if p.check(Identifier) && p.checkNext(Colon) {
+				name = p.advance()
+				p.advance()
+				hasNames = true
+			} else if hasNames {
+				_ = p.error(p.peek(), "Positional arguments cannot follow named arguments.")
+			}
+
 			expr, err := p.expression()
 			if err != nil {
 				return nil, err
 			}
 			arguments = append(arguments, expr)
+			names = append(names, name)
 			if !p.match(Comma) {
 				break
 			}
@@ -641,6 +1283,7 @@ func (p *Parser) finishCall(callee Expr) (Expr, error) {
 		Callee:    callee,
 		Paren:     paren,
 		Arguments: arguments,
+		Names:     names,
 	}, nil
 }
 
@@ -696,12 +1339,299 @@ func (p *Parser) primary() (Expr, error) {
 		return &GroupingExpr{
 			Expression: expr,
 		}, nil
+	case p.match(LeftBracket):
+		return p.list()
+	case p.match(LeftBrace):
+		return p.mapLiteral()
+	case p.match(Match):
+		return p.matchExpression()
 	default:
 		err := p.error(p.peek(), "Exprected expression.")
 		return nil, err
 	}
 }
 
+func (p *Parser) list() (Expr, error) {
+	elements := []Expr{}
+	if !p.check(RightBracket) {
+		for {
+			expr, err := p.expression()
+			if err != nil {
+				return nil, err
+			}
+			elements = append(elements, expr)
+			if !p.match(Comma) {
+				break
+			}
+		}
+	}
+
+	bracket, err := p.consume(RightBracket, "Expect ']' after list elements.")
+	if err != nil {
+		return nil, err
+	}
+
+	return &ListExpr{
+		Bracket:  bracket,
+		Elements: elements,
+	}, nil
+}
+
+func (p *Parser) mapLiteral() (Expr, error) {
+	keys := []Expr{}
+	values := []Expr{}
+	if !p.check(RightBrace) {
+		for {
+			key, err := p.expression()
+			if err != nil {
+				return nil, err
+			}
+			_, err = p.consume(Colon, "Expect ':' after map key.")
+			if err != nil {
+				return nil, err
+			}
+			value, err := p.expression()
+			if err != nil {
+				return nil, err
+			}
+			keys = append(keys, key)
+			values = append(values, value)
+			if !p.match(Comma) {
+				break
+			}
+		}
+	}
+
+	brace, err := p.consume(RightBrace, "Expect '}' after map entries.")
+	if err != nil {
+		return nil, err
+	}
+
+	return &MapExpr{
+		Brace:  brace,
+		Keys:   keys,
+		Values: values,
+	}, nil
+}
+
+func (p *Parser) matchExpression() (Expr, error) {
+	keyword := p.previous()
+	_, err := p.consume(LeftParen, "Expect '(' after 'match'.")
+	if err != nil {
+		return nil, err
+	}
+	value, err := p.expression()
+	if err != nil {
+		return nil, err
+	}
+	_, err = p.consume(RightParen, "Expect ')' after match value.")
+	if err != nil {
+		return nil, err
+	}
+	_, err = p.consume(LeftBrace, "Expect '{' before match arms.")
+	if err != nil {
+		return nil, err
+	}
+
+	arms := []*MatchArm{}
+	for !p.check(RightBrace) && !p.isAtEnd() {
+		pattern, err := p.pattern()
+		if err != nil {
+			return nil, err
+		}
+
+		var guard Expr
+		if p.match(If) {
+			guard, err = p.expression()
+			if err != nil {
+				return nil, err
+			}
+		}
+
+		_, err = p.consume(Arrow, "Expect '=>' after pattern.")
+		if err != nil {
+			return nil, err
+		}
+		body, err := p.expression()
+		if err != nil {
+			return nil, err
+		}
+
+		arms = append(arms, &MatchArm{
+			Pattern: pattern,
+			Guard:   guard,
+			Body:    body,
+		})
+		if !p.match(Comma) {
+			break
+		}
+	}
+
+	_, err = p.consume(RightBrace, "Expect '}' after match arms.")
+	if err != nil {
+		return nil, err
+	}
+
+	return &MatchExpr{
+		Keyword: keyword,
+		Value:   value,
+		Arms:    arms,
+	}, nil
+}
+
+func (p *Parser) pattern() (Pattern, error) {
+	pattern, err := p.singlePattern()
+	if err != nil {
+		return nil, err
+	}
+	if !p.check(Pipe) {
+		return pattern, nil
+	}
+
+	alternatives := []Pattern{pattern}
+	for p.match(Pipe) {
+		pattern, err = p.singlePattern()
+		if err != nil {
+			return nil, err
+		}
+		alternatives = append(alternatives, pattern)
+	}
+	return &AlternativePattern{Alternatives: alternatives}, nil
+}
+
+func (p *Parser) singlePattern() (Pattern, error) {
+	switch {
+	case p.match(False):
+		return &LiteralPattern{Token: p.previous(), Value: false}, nil
+	case p.match(True):
+		return &LiteralPattern{Token: p.previous(), Value: true}, nil
+	case p.match(Nil):
+		return &LiteralPattern{Token: p.previous(), Value: nil}, nil
+	case p.match(Number, String):
+		return &LiteralPattern{Token: p.previous(), Value: p.previous().Literal}, nil
+	case p.match(Minus):
+		number, err := p.consume(Number, "Expect number after '-' in pattern.")
+		if err != nil {
+			return nil, err
+		}
+		return &LiteralPattern{Token: number, Value: -number.Literal.(float64)}, nil
+	case p.match(Identifier):
+		name := p.previous()
+		if p.match(LeftParen) {
+			return p.classPattern(name)
+		}
+		if name.Lexeme == "_" {
+			return &WildcardPattern{Token: name}, nil
+		}
+		return &BindingPattern{Name: name}, nil
+	case p.match(LeftBracket):
+		return p.listPattern()
+	case p.match(LeftBrace):
+		brace := p.previous()
+		fields, err := p.fieldPatterns(RightBrace, "Expect '}' after field patterns.")
+		if err != nil {
+			return nil, err
+		}
+		return &ObjectPattern{
+			Brace:  brace,
+			Fields: fields,
+		}, nil
+	}
+
+	return nil, p.error(p.peek(), "Expect pattern.")
+}
+
+func (p *Parser) classPattern(name *Token) (Pattern, error) {
+	paren := p.previous()
+	fields, err := p.fieldPatterns(RightParen, "Expect ')' after field patterns.")
+	if err != nil {
+		return nil, err
+	}
+
+	return &ClassPattern{
+		Class:  &VarExpr{Name: name},
+		Paren:  paren,
+		Fields: fields,
+	}, nil
+}
+
+func (p *Parser) fieldPatterns(closing TokenType, message string) ([]*FieldPattern, error) {
+	fields := []*FieldPattern{}
+	if !p.check(closing) {
+		for {
+			field, err := p.consume(Identifier, "Expect field name.")
+			if err != nil {
+				return nil, err
+			}
+
+			// A bare field name binds the field to a variable
+			var pattern Pattern = &BindingPattern{Name: field}
+			if p.match(Colon) {
+				pattern, err = p.pattern()
+				if err != nil {
+					return nil, err
+				}
+			}
+
+			fields = append(fields, &FieldPattern{
+				Name:    field,
+				Pattern: pattern,
+			})
+			if !p.match(Comma) {
+				break
+			}
+		}
+	}
+
+	_, err := p.consume(closing, message)
+	if err != nil {
+		return nil, err
+	}
+	return fields, nil
+}
+
+func (p *Parser) listPattern() (Pattern, error) {
+	bracket := p.previous()
+	elements := []Pattern{}
+	var rest *Token
+	if !p.check(RightBracket) {
+		for {
+			// Rest collects remaining elements and must be last
+			if p.match(DotDotDot) {
+				var err error
+				rest, err = p.consume(Identifier, "Expect name after '...'.")
+				if err != nil {
+					return nil, err
+				}
+				if p.check(Comma) {
+					return nil, p.error(p.peek(), "Rest pattern must be last.")
+				}
+				break
+			}
+
+			element, err := p.pattern()
+			if err != nil {
+				return nil, err
+			}
+			elements = append(elements, element)
+			if !p.match(Comma) {
+				break
+			}
+		}
+	}
+
+	_, err := p.consume(RightBracket, "Expect ']' after list patterns.")
+	if err != nil {
+		return nil, err
+	}
+
+	return &ListPattern{
+		Bracket:  bracket,
+		Elements: elements,
+		Rest:     rest,
+	}, nil
+}
+
 func (p *Parser) match(types ...TokenType) bool {
 	for _, tokenType := range types {
 		if p.check(tokenType) {
@@ -727,6 +1657,22 @@ func (p *Parser) check(tokenType TokenType) bool {
 	return p.peek().Type == tokenType
 }
 
+// propertyName consumes the name after a '.', which can be a keyword
+// like `in`, since properties are looked up by name.
+func (p *Parser) propertyName() (*Token, error) {
+	if _, ok := keywords[p.peek().Lexeme]; ok {
+		return p.advance(), nil
+	}
+	return p.consume(Identifier, "Expect property name after '.'.")
+}
+
+func (p *Parser) checkNext(tokenType TokenType) bool {
+	if p.isAtEnd() || p.Tokens[p.Current+1].Type == EOF {
+		return false
+	}
+	return p.Tokens[p.Current+1].Type == tokenType
+}
+
 func (p *Parser) advance() *Token {
 	if !p.isAtEnd() {
 		p.Current++
@@ -755,7 +1701,7 @@ func (p *Parser) synchronize() {
 		}
 
 		switch p.peek().Type {
-		case Class, Fun, Var, For, If, While, Print, Return:
+		case Class, Async, Fun, Var, Const, For, If, While, Print, Return, Yield, Select, Switch, Break:
 			return
 		}
 
@@ -764,6 +1710,6 @@ func (p *Parser) synchronize() {
 }
 
 func (p *Parser) error(token *Token, msg string) error {
-	lox.errorToken(token, msg)
+	p.Lox.errorToken(token, msg)
 	return errors.New(msg)
 }
@@ -0,0 +1,75 @@
+package glox
+
+// Pattern is what a match arm tests its value against. Patterns aren't
+// evaluated like expressions, so they're walked with type switches
+// rather than visitors.
+type Pattern interface {
+	pattern()
+}
+
+// LiteralPattern matches values equal to a number, string, bool or nil.
+type LiteralPattern struct {
+	Token *Token
+	Value interface{}
+}
+
+func (t *LiteralPattern) pattern() {}
+
+// WildcardPattern is _, which matches anything.
+type WildcardPattern struct {
+	Token *Token
+}
+
+func (t *WildcardPattern) pattern() {}
+
+// BindingPattern matches anything, binding it to Name.
+type BindingPattern struct {
+	Name *Token
+}
+
+func (t *BindingPattern) pattern() {}
+
+// AlternativePattern matches if any of its alternatives do, as in
+// `1 | 2`.
+type AlternativePattern struct {
+	Alternatives []Pattern
+}
+
+func (t *AlternativePattern) pattern() {}
+
+// ClassPattern matches instances of Class with fields matching Fields,
+// as in `Point(x, y: 0)`.
+type ClassPattern struct {
+	Class *VarExpr
+	Paren *Token
+	Fields []*FieldPattern
+}
+
+func (t *ClassPattern) pattern() {}
+
+// FieldPattern matches an instance's field Name. A bare name binds the
+// field to a variable of the same name.
+type FieldPattern struct {
+	Name *Token
+	Pattern Pattern
+}
+
+// ObjectPattern matches instances with fields, or maps with string keys,
+// matching Fields, as in `{x, y}`.
+type ObjectPattern struct {
+	Brace *Token
+	Fields []*FieldPattern
+}
+
+func (t *ObjectPattern) pattern() {}
+
+// ListPattern matches lists element by element, as in `[a, b]`. With a
+// Rest, as in `[first, ...rest]`, longer lists match too and Rest gets
+// the remaining elements.
+type ListPattern struct {
+	Bracket *Token
+	Elements []Pattern
+	Rest *Token
+}
+
+func (t *ListPattern) pattern() {}
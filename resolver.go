@@ -1,4 +1,6 @@
-package main
+package glox
+
+import "fmt"
 
 type FunctionType int
 
@@ -7,6 +9,8 @@ const (
 	FunctionTypeFunction
 	FunctionTypeMethod
 	FunctionTypeInitializer
+	FunctionTypeGenerator
+	FunctionTypeAsync
 )
 
 type ClassType int
@@ -17,19 +21,35 @@ const (
 	ClassTypeSubclass
 )
 
+// Variable is what the resolver knows about a name in a scope.
+type Variable struct {
+	// False while the variable's initializer is being resolved
+	Defined  bool
+	Constant bool
+}
+
 type Resolver struct {
-	Interpreter     *Interpreter
-	Scopes          []map[string]bool
+	Interpreter *Interpreter
+	// Reports the errors found
+	Lox             *Lox
+	Scopes          []map[string]*Variable
 	CurrentFunction FunctionType
 	CurrentClass    ClassType
+	// How many loops and switches enclose the current statement in its
+	// function, for break
+	Breakable int
+	// Constants declared at the top level, which has no scope
+	GlobalConstants map[string]bool
 }
 
-func NewResolver(interpreter *Interpreter) *Resolver {
+func NewResolver(interpreter *Interpreter, lox *Lox) *Resolver {
 	return &Resolver{
 		Interpreter:     interpreter,
-		Scopes:          []map[string]bool{},
+		Lox:             lox,
+		Scopes:          []map[string]*Variable{},
 		CurrentFunction: FunctionTypeNone,
 		CurrentClass:    ClassTypeNone,
+		GlobalConstants: map[string]bool{},
 	}
 }
 
@@ -48,7 +68,7 @@ func (r *Resolver) resolveExpression(expr Expr) {
 }
 
 func (r *Resolver) beginScope() {
-	r.Scopes = append(r.Scopes, map[string]bool{})
+	r.Scopes = append(r.Scopes, map[string]*Variable{})
 }
 
 func (r *Resolver) endScope() {
@@ -65,10 +85,10 @@ func (r *Resolver) declare(name *Token) {
 
 	// Check if exists already in scope and error
 	if _, ok := scope[name.Lexeme]; ok {
-		lox.errorToken(name, "Variable with this name already declared in this scope.")
+		r.Lox.errorToken(name, "Variable with this name already declared in this scope.")
 	}
 
-	scope[name.Lexeme] = false
+	scope[name.Lexeme] = &Variable{}
 }
 
 func (r *Resolver) define(name *Token) {
@@ -77,7 +97,38 @@ func (r *Resolver) define(name *Token) {
 	}
 
 	scope := r.Scopes[len(r.Scopes)-1]
-	scope[name.Lexeme] = true
+	if variable, ok := scope[name.Lexeme]; ok {
+		variable.Defined = true
+	} else {
+		scope[name.Lexeme] = &Variable{Defined: true}
+	}
+}
+
+// defineConstant defines name as a variable that can't be assigned to.
+func (r *Resolver) defineConstant(name *Token) {
+	if len(r.Scopes) == 0 {
+		r.GlobalConstants[name.Lexeme] = true
+		return
+	}
+
+	r.define(name)
+	r.Scopes[len(r.Scopes)-1][name.Lexeme].Constant = true
+}
+
+// checkAssignment reports assignments to constants.
+func (r *Resolver) checkAssignment(name *Token) {
+	for i := len(r.Scopes) - 1; i >= 0; i-- {
+		if variable, ok := r.Scopes[i][name.Lexeme]; ok {
+			if variable.Constant {
+				r.Lox.errorToken(name, fmt.Sprintf("Cannot assign to constant '%s'.", name.Lexeme))
+			}
+			return
+		}
+	}
+
+	if r.GlobalConstants[name.Lexeme] {
+		r.Lox.errorToken(name, fmt.Sprintf("Cannot assign to constant '%s'.", name.Lexeme))
+	}
 }
 
 func (r *Resolver) resolveLocal(expr Expr, name *Token) {
@@ -94,17 +145,27 @@ func (r *Resolver) resolveLocal(expr Expr, name *Token) {
 
 func (r *Resolver) resolveFunction(function *FunctionStmt, functionType FunctionType) {
 	enclosingFunction := r.CurrentFunction
+	enclosingBreakable := r.Breakable
 	r.CurrentFunction = functionType
+	r.Breakable = 0
 	r.beginScope()
 
-	for _, param := range function.Params {
+	for idx, param := range function.Params {
+		if function.Defaults[idx] != nil {
+			r.resolveExpression(function.Defaults[idx])
+		}
 		r.declare(param)
 		r.define(param)
 	}
+	if function.Rest != nil {
+		r.declare(function.Rest)
+		r.define(function.Rest)
+	}
 
 	r.resolveStatements(function.Body)
 	r.endScope()
 	r.CurrentFunction = enclosingFunction
+	r.Breakable = enclosingBreakable
 }
 
 // Expressions
@@ -124,6 +185,80 @@ func (r *Resolver) VisitCallExpr(expr *CallExpr) (interface{}, *RuntimeError) {
 	return nil, nil
 }
 
+func (r *Resolver) VisitSpawnExpr(expr *SpawnExpr) (interface{}, *RuntimeError) {
+	r.resolveExpression(expr.Call)
+	return nil, nil
+}
+
+func (r *Resolver) VisitMatchExpr(expr *MatchExpr) (interface{}, *RuntimeError) {
+	r.resolveExpression(expr.Value)
+
+	catchAll := false
+	for _, arm := range expr.Arms {
+		// Each arm's bindings are only in scope for its guard and body
+		r.beginScope()
+		r.resolvePattern(arm.Pattern)
+		if arm.Guard != nil {
+			r.resolveExpression(arm.Guard)
+		}
+		r.resolveExpression(arm.Body)
+		r.endScope()
+
+		if arm.Guard == nil && isCatchAll(arm.Pattern) {
+			catchAll = true
+		}
+	}
+
+	// Values no arm matches are a runtime error
+	if !catchAll {
+		r.Lox.warn(expr.Keyword, "Match has no catch-all arm.")
+	}
+	return nil, nil
+}
+
+func (r *Resolver) resolvePattern(pattern Pattern) {
+	switch p := pattern.(type) {
+	case *BindingPattern:
+		r.declare(p.Name)
+		r.define(p.Name)
+	case *AlternativePattern:
+		for _, alternative := range p.Alternatives {
+			if bindsNames(alternative) {
+				r.Lox.errorToken(patternToken(alternative), "Cannot bind names in alternative patterns.")
+				continue
+			}
+			r.resolvePattern(alternative)
+		}
+	case *ClassPattern:
+		r.resolveExpression(p.Class)
+		for _, field := range p.Fields {
+			r.resolvePattern(field.Pattern)
+		}
+	case *ObjectPattern:
+		for _, field := range p.Fields {
+			r.resolvePattern(field.Pattern)
+		}
+	case *ListPattern:
+		for _, element := range p.Elements {
+			r.resolvePattern(element)
+		}
+		if p.Rest != nil {
+			r.declare(p.Rest)
+			r.define(p.Rest)
+		}
+	}
+}
+
+func (r *Resolver) VisitAwaitExpr(expr *AwaitExpr) (interface{}, *RuntimeError) {
+	// Top-level code can await too, running the event loop meanwhile
+	if r.CurrentFunction != FunctionTypeNone && r.CurrentFunction != FunctionTypeAsync {
+		r.Lox.errorToken(expr.Keyword, "Cannot await outside of an async function.")
+	}
+
+	r.resolveExpression(expr.Value)
+	return nil, nil
+}
+
 func (r *Resolver) VisitBinaryExpr(expr *BinaryExpr) (interface{}, *RuntimeError) {
 	r.resolveExpression(expr.Left)
 	r.resolveExpression(expr.Right)
@@ -148,9 +283,9 @@ func (r *Resolver) VisitVarExpr(expr *VarExpr) (interface{}, *RuntimeError) {
 	// Check if defined in local scope and if set to false (ie inside assignment)
 	if len(r.Scopes) > 0 {
 		localScope := r.Scopes[len(r.Scopes)-1]
-		if val, ok := localScope[expr.Name.Lexeme]; ok {
-			if val == false {
-				lox.errorToken(expr.Name, "Cannot read local variable in its own initializer.")
+		if variable, ok := localScope[expr.Name.Lexeme]; ok {
+			if !variable.Defined {
+				r.Lox.errorToken(expr.Name, "Cannot read local variable in its own initializer.")
 			}
 		}
 	}
@@ -161,10 +296,41 @@ func (r *Resolver) VisitVarExpr(expr *VarExpr) (interface{}, *RuntimeError) {
 
 func (r *Resolver) VisitAssignExpr(expr *AssignExpr) (interface{}, *RuntimeError) {
 	r.resolveExpression(expr.Value)
+	r.checkAssignment(expr.Name)
 	r.resolveLocal(expr, expr.Name)
 	return nil, nil
 }
 
+func (r *Resolver) VisitDestructureExpr(expr *DestructureExpr) (interface{}, *RuntimeError) {
+	r.resolveExpression(expr.Value)
+	for _, target := range expr.Targets {
+		r.resolveTarget(target)
+	}
+	return nil, nil
+}
+
+func (r *Resolver) resolveTarget(target Expr) {
+	switch t := target.(type) {
+	case *VarExpr:
+		r.checkAssignment(t.Name)
+		r.resolveLocal(t, t.Name)
+	case *ListExpr:
+		for _, element := range t.Elements {
+			r.resolveTarget(element)
+		}
+	default:
+		// Properties and indexes only need their object resolving
+		r.resolveExpression(target)
+	}
+}
+
+func (r *Resolver) VisitConditionalExpr(expr *ConditionalExpr) (interface{}, *RuntimeError) {
+	r.resolveExpression(expr.Condition)
+	r.resolveExpression(expr.Then)
+	r.resolveExpression(expr.Else)
+	return nil, nil
+}
+
 func (r *Resolver) VisitGetExpr(expr *GetExpr) (interface{}, *RuntimeError) {
 	r.resolveExpression(expr.Object)
 	return nil, nil
@@ -176,9 +342,37 @@ func (r *Resolver) VisitSetExpr(expr *SetExpr) (interface{}, *RuntimeError) {
 	return nil, nil
 }
 
+func (r *Resolver) VisitListExpr(expr *ListExpr) (interface{}, *RuntimeError) {
+	for _, element := range expr.Elements {
+		r.resolveExpression(element)
+	}
+	return nil, nil
+}
+
+func (r *Resolver) VisitMapExpr(expr *MapExpr) (interface{}, *RuntimeError) {
+	for idx := range expr.Keys {
+		r.resolveExpression(expr.Keys[idx])
+		r.resolveExpression(expr.Values[idx])
+	}
+	return nil, nil
+}
+
+func (r *Resolver) VisitIndexExpr(expr *IndexExpr) (interface{}, *RuntimeError) {
+	r.resolveExpression(expr.Object)
+	r.resolveExpression(expr.Index)
+	return nil, nil
+}
+
+func (r *Resolver) VisitIndexSetExpr(expr *IndexSetExpr) (interface{}, *RuntimeError) {
+	r.resolveExpression(expr.Value)
+	r.resolveExpression(expr.Object)
+	r.resolveExpression(expr.Index)
+	return nil, nil
+}
+
 func (r *Resolver) VisitThisExpr(expr *ThisExpr) (interface{}, *RuntimeError) {
 	if r.CurrentClass == ClassTypeNone {
-		lox.errorToken(expr.Keyword, "Cannot use 'this' outside of a class.")
+		r.Lox.errorToken(expr.Keyword, "Cannot use 'this' outside of a class.")
 		return nil, nil
 	}
 
@@ -188,9 +382,9 @@ func (r *Resolver) VisitThisExpr(expr *ThisExpr) (interface{}, *RuntimeError) {
 
 func (r *Resolver) VisitSuperExpr(expr *SuperExpr) (interface{}, *RuntimeError) {
 	if r.CurrentClass == ClassTypeNone {
-		lox.errorToken(expr.Keyword, "Cannot use 'super' outside of a class.")
+		r.Lox.errorToken(expr.Keyword, "Cannot use 'super' outside of a class.")
 	} else if r.CurrentClass != ClassTypeSubclass {
-		lox.errorToken(expr.Keyword, "Cannot use 'super' in a class with no superclass.")
+		r.Lox.errorToken(expr.Keyword, "Cannot use 'super' in a class with no superclass.")
 	}
 	r.resolveLocal(expr, expr.Keyword)
 	return nil, nil
@@ -216,7 +410,105 @@ func (r *Resolver) VisitIfStmt(stmt *IfStmt) (interface{}, *RuntimeError) {
 
 func (r *Resolver) VisitWhileStmt(stmt *WhileStmt) (interface{}, *RuntimeError) {
 	r.resolveExpression(stmt.Condition)
+	r.Breakable++
+	r.resolveStatement(stmt.Body)
+	r.Breakable--
+	return nil, nil
+}
+
+func (r *Resolver) VisitForInStmt(stmt *ForInStmt) (interface{}, *RuntimeError) {
+	r.resolveExpression(stmt.Iterable)
+
+	// Each iteration gets a scope for its variable
+	r.beginScope()
+	r.declare(stmt.Name)
+	r.define(stmt.Name)
+	r.Breakable++
 	r.resolveStatement(stmt.Body)
+	r.Breakable--
+	r.endScope()
+	return nil, nil
+}
+
+func (r *Resolver) VisitSelectStmt(stmt *SelectStmt) (interface{}, *RuntimeError) {
+	for _, selectCase := range stmt.Cases {
+		r.resolveExpression(selectCase.Channel)
+		if selectCase.Value != nil {
+			r.resolveExpression(selectCase.Value)
+		}
+
+		r.beginScope()
+		if selectCase.Name != nil {
+			r.declare(selectCase.Name)
+			r.define(selectCase.Name)
+		}
+		r.resolveStatements(selectCase.Body)
+		r.endScope()
+	}
+
+	if stmt.Default != nil {
+		r.beginScope()
+		r.resolveStatements(stmt.Default)
+		r.endScope()
+	}
+	return nil, nil
+}
+
+func (r *Resolver) VisitSwitchStmt(stmt *SwitchStmt) (interface{}, *RuntimeError) {
+	r.resolveExpression(stmt.Value)
+
+	r.Breakable++
+	// Literal values can only match once, so later cases with them
+	// could never run
+	literals := map[interface{}]bool{}
+	for _, switchCase := range stmt.Cases {
+		for _, value := range switchCase.Values {
+			r.resolveExpression(value)
+			if literal, ok := caseLiteral(value); ok {
+				if literals[literal] {
+					r.Lox.errorToken(switchCase.Keyword, "Duplicate case value.")
+				}
+				literals[literal] = true
+			}
+		}
+
+		r.beginScope()
+		r.resolveStatements(switchCase.Body)
+		r.endScope()
+	}
+
+	if stmt.Default != nil {
+		r.beginScope()
+		r.resolveStatements(stmt.Default)
+		r.endScope()
+	}
+	r.Breakable--
+	return nil, nil
+}
+
+// caseLiteral returns the value of a literal case, counting negative
+// numbers, which parse as a minus and a literal.
+func caseLiteral(value Expr) (interface{}, bool) {
+	if unary, ok := value.(*UnaryExpr); ok && unary.Operator.Type == Minus {
+		if literal, ok := unary.Right.(*LiteralExpr); ok {
+			if num, ok := literal.Value.(float64); ok {
+				return -num, true
+			}
+		}
+		return nil, false
+	}
+
+	literal, ok := value.(*LiteralExpr)
+	if !ok {
+		return nil, false
+	}
+	return literal.Value, true
+}
+
+func (r *Resolver) VisitBreakStmt(stmt *BreakStmt) (interface{}, *RuntimeError) {
+	if r.Breakable == 0 {
+		r.Lox.errorToken(stmt.Keyword, "Cannot break outside of a loop or switch.")
+	}
 	return nil, nil
 }
 
@@ -224,21 +516,49 @@ func (r *Resolver) VisitFunctionStmt(stmt *FunctionStmt) (interface{}, *RuntimeE
 	r.declare(stmt.Name)
 	r.define(stmt.Name)
 
-	r.resolveFunction(stmt, FunctionTypeFunction)
+	functionType := FunctionTypeFunction
+	if stmt.IsGenerator {
+		functionType = FunctionTypeGenerator
+	}
+	if stmt.IsAsync {
+		functionType = FunctionTypeAsync
+	}
+	r.resolveFunction(stmt, functionType)
 	return nil, nil
 }
 
 func (r *Resolver) VisitReturnStmt(stmt *ReturnStmt) (interface{}, *RuntimeError) {
 	if r.CurrentFunction == FunctionTypeNone {
-		lox.errorToken(stmt.Keyword, "Cannot return from top-level code.")
+		r.Lox.errorToken(stmt.Keyword, "Cannot return from top-level code.")
 	}
 
 	if stmt.Value != nil {
 		if r.CurrentFunction == FunctionTypeInitializer {
-			lox.errorToken(stmt.Keyword, "Cannot return a value from an initializer.")
+			r.Lox.errorToken(stmt.Keyword, "Cannot return a value from an initializer.")
+		}
+		if r.CurrentFunction == FunctionTypeGenerator {
+			r.Lox.errorToken(stmt.Keyword, "Cannot return a value from a generator.")
 		}
 
 		r.resolveExpression(stmt.Value)
+
+		// Nothing is left to do after a returned call, so it can
+		// reuse the function's frame, unless a ?. can skip it. Async
+		// bodies return through their promise instead.
+		if call, ok := stmt.Value.(*CallExpr); ok && !inOptionalChain(call) {
+			stmt.TailCall = r.CurrentFunction != FunctionTypeNone && r.CurrentFunction != FunctionTypeAsync
+		}
+	}
+	return nil, nil
+}
+
+func (r *Resolver) VisitYieldStmt(stmt *YieldStmt) (interface{}, *RuntimeError) {
+	if r.CurrentFunction != FunctionTypeGenerator {
+		r.Lox.errorToken(stmt.Keyword, "Cannot yield outside of a generator.")
+	}
+
+	if stmt.Value != nil {
+		r.resolveExpression(stmt.Value)
 	}
 	return nil, nil
 }
@@ -253,7 +573,21 @@ func (r *Resolver) VisitVarStmt(stmt *VarStmt) (interface{}, *RuntimeError) {
 	if stmt.Initializer != nil {
 		r.resolveExpression(stmt.Initializer)
 	}
-	r.define(stmt.Name)
+	if stmt.IsConst {
+		r.defineConstant(stmt.Name)
+	} else {
+		r.define(stmt.Name)
+		if len(r.Scopes) == 0 {
+			// Redeclaring a global replaces it
+			delete(r.GlobalConstants, stmt.Name.Lexeme)
+		}
+	}
+	return nil, nil
+}
+
+func (r *Resolver) VisitVarPatternStmt(stmt *VarPatternStmt) (interface{}, *RuntimeError) {
+	r.resolveExpression(stmt.Initializer)
+	r.resolvePattern(stmt.Pattern)
 	return nil, nil
 }
 
@@ -264,7 +598,7 @@ func (r *Resolver) VisitClassStmt(stmt *ClassStmt) (interface{}, *RuntimeError)
 	r.define(stmt.Name)
 
 	if stmt.Superclass != nil && stmt.Superclass.Name.Lexeme == stmt.Name.Lexeme {
-		lox.errorToken(stmt.Superclass.Name, "A class cannot inherit from itself.")
+		r.Lox.errorToken(stmt.Superclass.Name, "A class cannot inherit from itself.")
 	}
 
 	if stmt.Superclass != nil {
@@ -274,16 +608,26 @@ func (r *Resolver) VisitClassStmt(stmt *ClassStmt) (interface{}, *RuntimeError)
 
 	if stmt.Superclass != nil {
 		r.beginScope()
-		r.Scopes[len(r.Scopes)-1]["super"] = true
+		r.Scopes[len(r.Scopes)-1]["super"] = &Variable{Defined: true}
 	}
 
 	r.beginScope()
-	r.Scopes[len(r.Scopes)-1]["this"] = true
+	r.Scopes[len(r.Scopes)-1]["this"] = &Variable{Defined: true}
 
 	for _, method := range stmt.Methods {
 		declaration := FunctionTypeMethod
 		if method.Name.Lexeme == "init" {
 			declaration = FunctionTypeInitializer
+			if method.IsAsync {
+				r.Lox.errorToken(method.Name, "Initializer cannot be async.")
+			}
+			if method.IsGenerator {
+				r.Lox.errorToken(method.Name, "Initializer cannot be a generator.")
+			}
+		} else if method.IsGenerator {
+			declaration = FunctionTypeGenerator
+		} else if method.IsAsync {
+			declaration = FunctionTypeAsync
 		}
 		r.resolveFunction(method, declaration)
 	}
@@ -295,3 +639,74 @@ func (r *Resolver) VisitClassStmt(stmt *ClassStmt) (interface{}, *RuntimeError)
 	r.CurrentClass = enclosingClass
 	return nil, nil
 }
+
+// isCatchAll reports whether pattern matches every value.
+func isCatchAll(pattern Pattern) bool {
+	switch p := pattern.(type) {
+	case *WildcardPattern, *BindingPattern:
+		return true
+	case *AlternativePattern:
+		for _, alternative := range p.Alternatives {
+			if isCatchAll(alternative) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+func bindsNames(pattern Pattern) bool {
+	switch p := pattern.(type) {
+	case *BindingPattern:
+		return true
+	case *AlternativePattern:
+		for _, alternative := range p.Alternatives {
+			if bindsNames(alternative) {
+				return true
+			}
+		}
+	case *ClassPattern:
+		for _, field := range p.Fields {
+			if bindsNames(field.Pattern) {
+				return true
+			}
+		}
+	case *ObjectPattern:
+		for _, field := range p.Fields {
+			if bindsNames(field.Pattern) {
+				return true
+			}
+		}
+	case *ListPattern:
+		if p.Rest != nil {
+			return true
+		}
+		for _, element := range p.Elements {
+			if bindsNames(element) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// patternToken returns a token to report errors in pattern at.
+func patternToken(pattern Pattern) *Token {
+	switch p := pattern.(type) {
+	case *LiteralPattern:
+		return p.Token
+	case *WildcardPattern:
+		return p.Token
+	case *BindingPattern:
+		return p.Name
+	case *AlternativePattern:
+		return patternToken(p.Alternatives[0])
+	case *ClassPattern:
+		return p.Class.Name
+	case *ObjectPattern:
+		return p.Brace
+	case *ListPattern:
+		return p.Bracket
+	}
+	return nil
+}
@@ -1,4 +1,4 @@
-package main
+package glox
 
 import (
 	"strconv"
@@ -11,11 +11,14 @@ type Scanner struct {
 	Start int
 	Current int
 	Line int
+	// Reports the errors found
+	Lox *Lox
 }
 
-func makeScanner(source string) *Scanner {
+func makeScanner(source string, lox *Lox) *Scanner {
 	return &Scanner{
 		Source: source,
+		Lox: lox,
 		Start: 0,
 		Current: 0,
 		Line: 1,
@@ -50,18 +53,68 @@ func (s *Scanner) scanToken() {
 			s.addToken(LeftBrace)
 		case '}':
 			s.addToken(RightBrace)
+		case '[':
+			s.addToken(LeftBracket)
+		case ']':
+			s.addToken(RightBracket)
 		case ',':
 			s.addToken(Comma)
+		case ':':
+			s.addToken(Colon)
 		case '.':
-			s.addToken(Dot)
+			if s.peek() == '.' && s.peekNext() == '.' {
+				s.advance()
+				s.advance()
+				s.addToken(DotDotDot)
+			} else {
+				s.addToken(Dot)
+			}
 		case '-':
-			s.addToken(Minus)
+			if s.match('-') {
+				s.addToken(MinusMinus)
+			} else if s.match('=') {
+				s.addToken(MinusEqual)
+			} else {
+				s.addToken(Minus)
+			}
 		case '+':
-			s.addToken(Plus)
+			if s.match('+') {
+				s.addToken(PlusPlus)
+			} else if s.match('=') {
+				s.addToken(PlusEqual)
+			} else {
+				s.addToken(Plus)
+			}
+		case '%':
+			if s.match('=') {
+				s.addToken(PercentEqual)
+			} else {
+				s.addToken(Percent)
+			}
+		case '?':
+			if s.peek() == '.' && isDigit(s.peekNext()) {
+				// a?.5:1 is a conditional with 0.5, not a ?.
+				s.addToken(Question)
+				s.Start = s.Current
+				s.advance()
+				s.number()
+			} else if s.match('.') {
+				s.addToken(QuestionDot)
+			} else if s.match('?') {
+				s.addToken(QuestionQuestion)
+			} else {
+				s.addToken(Question)
+			}
 		case ';':
 			s.addToken(Semicolon)
 		case '*':
-			s.addToken(Star)
+			if s.match('=') {
+				s.addToken(StarEqual)
+			} else {
+				s.addToken(Star)
+			}
+		case '|':
+			s.addToken(Pipe)
 		case '!':
 			if s.match('=') {
 				s.addToken(BangEqual)
@@ -71,6 +124,8 @@ func (s *Scanner) scanToken() {
 		case '=':
 			if s.match('=') {
 				s.addToken(EqualEqual)
+			} else if s.match('>') {
+				s.addToken(Arrow)
 			} else {
 				s.addToken(Equal)
 			}
@@ -91,6 +146,8 @@ func (s *Scanner) scanToken() {
 				for s.peek() != '\n' && !s.isAtEnd() {
 					s.advance()
 				}
+			} else if s.match('=') {
+				s.addToken(SlashEqual)
 			} else {
 				s.addToken(Slash)
 			}
@@ -105,7 +162,7 @@ func (s *Scanner) scanToken() {
 			} else if isAlpha(char) {
 				s.identifier()
 			} else {
-				lox.errorLine(s.Line, "Unexpected character.")
+				s.Lox.errorLine(s.Line, "Unexpected character.")
 			}
 	}
 }
@@ -165,7 +222,7 @@ func (s *Scanner) string() {
 	}
 
 	if s.isAtEnd() {
-		lox.errorLine(s.Line, "Unterminated string.")
+		s.Lox.errorLine(s.Line, "Unterminated string.")
 	}
 
 	s.advance()
@@ -189,7 +246,7 @@ func (s *Scanner) number() {
 
 	value, err := strconv.ParseFloat(s.Source[s.Start:s.Current], 64)
 	if err != nil {
-		lox.errorLine(s.Line, "Ivalid number.")
+		s.Lox.errorLine(s.Line, "Ivalid number.")
 	}
 	s.addTokenValue(Number, value)
 }
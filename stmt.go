@@ -1,4 +1,4 @@
-package main
+package glox
 
 type Stmt interface {
 	Accept(StmtVisitor) (interface{}, *RuntimeError)
@@ -6,11 +6,17 @@ type Stmt interface {
 
 type StmtVisitor interface {
 	VisitVarStmt(*VarStmt) (interface{}, *RuntimeError)
+	VisitVarPatternStmt(*VarPatternStmt) (interface{}, *RuntimeError)
 	VisitBlockStmt(*BlockStmt) (interface{}, *RuntimeError)
 	VisitIfStmt(*IfStmt) (interface{}, *RuntimeError)
 	VisitWhileStmt(*WhileStmt) (interface{}, *RuntimeError)
+	VisitForInStmt(*ForInStmt) (interface{}, *RuntimeError)
+	VisitSelectStmt(*SelectStmt) (interface{}, *RuntimeError)
+	VisitSwitchStmt(*SwitchStmt) (interface{}, *RuntimeError)
+	VisitBreakStmt(*BreakStmt) (interface{}, *RuntimeError)
 	VisitFunctionStmt(*FunctionStmt) (interface{}, *RuntimeError)
 	VisitReturnStmt(*ReturnStmt) (interface{}, *RuntimeError)
+	VisitYieldStmt(*YieldStmt) (interface{}, *RuntimeError)
 	VisitClassStmt(*ClassStmt) (interface{}, *RuntimeError)
 	VisitExpressionStmt(*ExpressionStmt) (interface{}, *RuntimeError)
 }
@@ -36,12 +42,25 @@ func (t *ExpressionStmt) Accept(visitor StmtVisitor) (interface{}, *RuntimeError
 type VarStmt struct {
 	Name *Token
 	Initializer Expr
+	// Declared with const, so it can't be assigned to
+	IsConst bool
 }
 
 func (t *VarStmt) Accept(visitor StmtVisitor) (interface{}, *RuntimeError) {
 	return visitor.VisitVarStmt(t)
 }
 
+// VarPatternStmt declares the names in Pattern, as in `var [a, b] = pair;`
+type VarPatternStmt struct {
+	Keyword *Token
+	Pattern Pattern
+	Initializer Expr
+}
+
+func (t *VarPatternStmt) Accept(visitor StmtVisitor) (interface{}, *RuntimeError) {
+	return visitor.VisitVarPatternStmt(t)
+}
+
 type BlockStmt struct {
 	Statements []Stmt
 }
@@ -61,6 +80,7 @@ func (t *IfStmt) Accept(visitor StmtVisitor) (interface{}, *RuntimeError) {
 }
 
 type WhileStmt struct {
+	Keyword *Token
 	Condition Expr
 	Body Stmt
 }
@@ -69,10 +89,77 @@ func (t *WhileStmt) Accept(visitor StmtVisitor) (interface{}, *RuntimeError) {
 	return visitor.VisitWhileStmt(t)
 }
 
+type ForInStmt struct {
+	Keyword *Token
+	Name *Token
+	Iterable Expr
+	Body Stmt
+}
+
+func (t *ForInStmt) Accept(visitor StmtVisitor) (interface{}, *RuntimeError) {
+	return visitor.VisitForInStmt(t)
+}
+
+type SelectStmt struct {
+	Keyword *Token
+	Cases []*SelectCase
+	// Nil without a default case, so select waits
+	Default []Stmt
+}
+
+func (t *SelectStmt) Accept(visitor StmtVisitor) (interface{}, *RuntimeError) {
+	return visitor.VisitSelectStmt(t)
+}
+
+// SelectCase is a channel's send() or recv() in a select, optionally
+// with a variable for the value received.
+type SelectCase struct {
+	Keyword *Token
+	Name *Token
+	Channel Expr
+	// Nil for recv()
+	Value Expr
+	IsSend bool
+	Body []Stmt
+}
+
+type SwitchStmt struct {
+	Keyword *Token
+	Value Expr
+	Cases []*SwitchCase
+	// Nil without a default case
+	Default []Stmt
+}
+
+func (t *SwitchStmt) Accept(visitor StmtVisitor) (interface{}, *RuntimeError) {
+	return visitor.VisitSwitchStmt(t)
+}
+
+// SwitchCase runs Body when the switch's value equals any of Values.
+type SwitchCase struct {
+	Keyword *Token
+	Values []Expr
+	Body []Stmt
+}
+
+type BreakStmt struct {
+	Keyword *Token
+}
+
+func (t *BreakStmt) Accept(visitor StmtVisitor) (interface{}, *RuntimeError) {
+	return visitor.VisitBreakStmt(t)
+}
+
 type FunctionStmt struct {
 	Name *Token
 	Params []*Token
+	Defaults []Expr
+	Rest *Token
 	Body []Stmt
+	// Declared with fun*, so calls return a generator
+	IsGenerator bool
+	// Declared with async, so calls return a promise
+	IsAsync bool
 }
 
 func (t *FunctionStmt) Accept(visitor StmtVisitor) (interface{}, *RuntimeError) {
@@ -82,9 +169,21 @@ func (t *FunctionStmt) Accept(visitor StmtVisitor) (interface{}, *RuntimeError)
 type ReturnStmt struct {
 	Keyword *Token
 	Value Expr
+	// Set by the resolver when Value is a call the function can hand
+	// its stack frame to
+	TailCall bool
 }
 
 func (t *ReturnStmt) Accept(visitor StmtVisitor) (interface{}, *RuntimeError) {
 	return visitor.VisitReturnStmt(t)
 }
 
+type YieldStmt struct {
+	Keyword *Token
+	Value Expr
+}
+
+func (t *YieldStmt) Accept(visitor StmtVisitor) (interface{}, *RuntimeError) {
+	return visitor.VisitYieldStmt(t)
+}
+
@@ -1,21 +1,34 @@
-package main
+package glox
 
 var keywords = map[string]TokenType{
-	"and":    And,
-	"class":  Class,
-	"else":   Else,
-	"false":  False,
-	"for":    For,
-	"fun":    Fun,
-	"if":     If,
-	"nil":    Nil,
-	"or":     Or,
-	"return": Return,
-	"super":  Super,
-	"this":   This,
-	"true":   True,
-	"var":    Var,
-	"while":  While,
+	"and":     And,
+	"async":   Async,
+	"await":   Await,
+	"break":   Break,
+	"case":    Case,
+	"class":   Class,
+	"const":   Const,
+	"default": Default,
+	"else":    Else,
+	"false":   False,
+	"for":     For,
+	"fun":     Fun,
+	"if":      If,
+	"in":      In,
+	"is":      Is,
+	"match":   Match,
+	"nil":     Nil,
+	"or":      Or,
+	"return":  Return,
+	"select":  Select,
+	"spawn":   Spawn,
+	"super":   Super,
+	"switch":  Switch,
+	"this":    This,
+	"true":    True,
+	"var":     Var,
+	"while":   While,
+	"yield":   Yield,
 }
 
 // TokenType is an enum
@@ -27,15 +40,23 @@ const (
 	RightParen
 	LeftBrace
 	RightBrace
+	LeftBracket
+	RightBracket
 	Comma
+	Colon
 	Dot
+	DotDotDot
 	Minus
+	Percent
+	Pipe
 	Plus
+	Question
 	Semicolon
 	Slash
 	Star
 
 	// One or two character tokens.
+	Arrow
 	Bang
 	BangEqual
 	Equal
@@ -44,6 +65,15 @@ const (
 	GreaterEqual
 	Less
 	LessEqual
+	MinusEqual
+	MinusMinus
+	PercentEqual
+	PlusEqual
+	PlusPlus
+	QuestionDot
+	QuestionQuestion
+	SlashEqual
+	StarEqual
 
 	// Literals.
 	Identifier
@@ -52,21 +82,34 @@ const (
 
 	// Keywords.
 	And
+	Async
+	Await
+	Break
+	Case
 	Class
+	Const
+	Default
 	Else
 	False
 	Fun
 	For
 	If
+	In
+	Is
+	Match
 	Nil
 	Or
 	Print
 	Return
+	Select
+	Spawn
 	Super
+	Switch
 	This
 	True
 	Var
 	While
+	Yield
 
 	EOF
 )
@@ -81,20 +124,36 @@ func (t TokenType) String() string {
 		return "LeftBrace"
 	case RightBrace:
 		return "RightBrace"
+	case LeftBracket:
+		return "LeftBracket"
+	case RightBracket:
+		return "RightBracket"
 	case Comma:
 		return "Comma"
+	case Colon:
+		return "Colon"
 	case Dot:
 		return "Dot"
+	case DotDotDot:
+		return "DotDotDot"
 	case Minus:
 		return "Minus"
+	case Percent:
+		return "Percent"
+	case Pipe:
+		return "Pipe"
 	case Plus:
 		return "Plus"
+	case Question:
+		return "Question"
 	case Semicolon:
 		return "Semicolon"
 	case Slash:
 		return "Slash"
 	case Star:
 		return "Star"
+	case Arrow:
+		return "Arrow"
 	case Bang:
 		return "Bang"
 	case BangEqual:
@@ -111,6 +170,24 @@ func (t TokenType) String() string {
 		return "Less"
 	case LessEqual:
 		return "LessEqual"
+	case MinusEqual:
+		return "MinusEqual"
+	case MinusMinus:
+		return "MinusMinus"
+	case PercentEqual:
+		return "PercentEqual"
+	case PlusEqual:
+		return "PlusEqual"
+	case PlusPlus:
+		return "PlusPlus"
+	case QuestionDot:
+		return "QuestionDot"
+	case QuestionQuestion:
+		return "QuestionQuestion"
+	case SlashEqual:
+		return "SlashEqual"
+	case StarEqual:
+		return "StarEqual"
 	case Identifier:
 		return "Identifier"
 	case String:
@@ -119,8 +196,20 @@ func (t TokenType) String() string {
 		return "Number"
 	case And:
 		return "And"
+	case Async:
+		return "Async"
+	case Await:
+		return "Await"
+	case Break:
+		return "Break"
+	case Case:
+		return "Case"
 	case Class:
 		return "Class"
+	case Const:
+		return "Const"
+	case Default:
+		return "Default"
 	case Else:
 		return "Else"
 	case False:
@@ -131,6 +220,12 @@ func (t TokenType) String() string {
 		return "For"
 	case If:
 		return "If"
+	case In:
+		return "In"
+	case Is:
+		return "Is"
+	case Match:
+		return "Match"
 	case Nil:
 		return "Nil"
 	case Or:
@@ -139,8 +234,14 @@ func (t TokenType) String() string {
 		return "Print"
 	case Return:
 		return "Return"
+	case Select:
+		return "Select"
+	case Spawn:
+		return "Spawn"
 	case Super:
 		return "Super"
+	case Switch:
+		return "Switch"
 	case This:
 		return "This"
 	case True:
@@ -149,6 +250,8 @@ func (t TokenType) String() string {
 		return "Var"
 	case While:
 		return "While"
+	case Yield:
+		return "Yield"
 	case EOF:
 		return "EOF"
 	default: